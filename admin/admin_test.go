@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+func TestLevelHandlerInterface(t *testing.T) {
+	var _ http.Handler = &LevelHandler{}
+}
+
+func do(t *testing.T, h http.Handler, method, body string) (int, LevelState) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/loggers", strings.NewReader(body)))
+	var s LevelState
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+			t.Fatalf("Failed to decode state: %v", err)
+		}
+	}
+	return rec.Code, s
+}
+
+func TestLevelHandlerGet(t *testing.T) {
+	r := named.NewRegistry(mappers.LevelInfo)
+	r.SetLevel("db", mappers.LevelDebug)
+
+	code, s := do(t, NewLevelHandler(r), http.MethodGet, "")
+	if code != http.StatusOK || s.Level != mappers.LevelInfo || s.Loggers["db"] != mappers.LevelDebug {
+		t.Errorf("State mismatch %d %+v", code, s)
+	}
+}
+
+func TestLevelHandlerChange(t *testing.T) {
+	r := named.NewRegistry(mappers.LevelInfo)
+	h := NewLevelHandler(r)
+
+	if code, s := do(t, h, http.MethodPut, `{"level":"warn"}`); code != http.StatusOK || s.Level != mappers.LevelWarn {
+		t.Errorf("Root change mismatch %d %+v", code, s)
+	}
+	if code, _ := do(t, h, http.MethodPost, `{"logger":"http.client","level":"error"}`); code != http.StatusOK {
+		t.Errorf("Named change failed with %d", code)
+	}
+	if r.Level("http.client.pool") != mappers.LevelError {
+		t.Errorf("Named change must apply to children")
+	}
+	if code, s := do(t, h, http.MethodPut, `{"logger":"http.client","level":""}`); code != http.StatusOK || len(s.Loggers) != 0 {
+		t.Errorf("Unset mismatch %d %+v", code, s)
+	}
+}
+
+func TestLevelHandlerErrors(t *testing.T) {
+	h := NewLevelHandler(named.NewRegistry(mappers.LevelInfo))
+	tests := map[string]int{
+		`{"level":"loud"}`:              http.StatusBadRequest,
+		`{"level":""}`:                  http.StatusBadRequest,
+		`{"level":"debug","ttl":"-1s"}`: http.StatusBadRequest,
+		`{"lvl":"debug"}`:               http.StatusBadRequest,
+	}
+	for body, expected := range tests {
+		if code, _ := do(t, h, http.MethodPut, body); code != expected {
+			t.Errorf("Status for %s mismatch %d (actual) != %d (expected)", body, code, expected)
+		}
+	}
+	if code, _ := do(t, h, http.MethodDelete, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("Status for DELETE mismatch %d (actual) != %d (expected)", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestLevelHandlerTTL(t *testing.T) {
+	r := named.NewRegistry(mappers.LevelInfo)
+	h := NewLevelHandler(r)
+
+	do(t, h, http.MethodPut, `{"logger":"db","level":"debug","ttl":"1h"}`)
+	code, s := do(t, h, http.MethodPut, `{"logger":"db","level":"warn","ttl":"20ms"}`)
+	if code != http.StatusOK || s.Loggers["db"] != mappers.LevelWarn || s.Expires["db"].IsZero() {
+		t.Fatalf("Temporary change mismatch %d %+v", code, s)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(h.State().Expires) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s = h.State()
+	if _, ok := s.Loggers["db"]; ok || len(s.Expires) != 0 {
+		t.Errorf("Expired changes must restore the original state, got %+v", s)
+	}
+}
@@ -0,0 +1,187 @@
+// Package admin provides HTTP handlers to inspect and control logging at runtime.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+// LevelHandler is an http.Handler reporting and changing the levels of a named.Registry.
+//
+// GET returns the levels as a LevelState. PUT and POST apply a LevelChange
+// and return the resulting LevelState. A change with a TTL is reverted once it expires,
+// restoring the level the logger had before the first of its pending changes.
+type LevelHandler struct {
+	registry *named.Registry
+
+	mu      sync.Mutex
+	pending map[string]*pendingRestore
+}
+
+// LevelState is the JSON document describing the levels of a registry.
+type LevelState struct {
+	// Level is the root, global, level.
+	Level mappers.Level `json:"level"`
+	// Loggers are the levels set by logger name.
+	Loggers map[string]mappers.Level `json:"loggers"`
+	// Expires are the times at which temporary changes are reverted, by logger name.
+	Expires map[string]time.Time `json:"expires,omitempty"`
+}
+
+// LevelChange is the JSON document accepted to change a level.
+type LevelChange struct {
+	// Logger is the logger name, empty for the root level.
+	Logger string `json:"logger,omitempty"`
+	// Level is the new level. An empty level unsets the level of a named logger,
+	// which then inherits the level of its parent.
+	Level string `json:"level"`
+	// TTL, such as "10m", makes the change temporary.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// pendingRestore is a temporary change waiting to be reverted.
+type pendingRestore struct {
+	timer   *time.Timer
+	expires time.Time
+	// level is the level to restore, unset when the logger had no level of its own.
+	level mappers.Level
+	unset bool
+}
+
+// NewLevelHandler returns a LevelHandler for r. A nil r uses named.DefaultRegistry.
+func NewLevelHandler(r *named.Registry) *LevelHandler {
+	if r == nil {
+		r = named.DefaultRegistry
+	}
+	return &LevelHandler{registry: r, pending: make(map[string]*pendingRestore)}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodPost:
+		var c LevelChange
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid level change: %w", err))
+			return
+		}
+		if err := h.Apply(c); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.State())
+}
+
+// State returns the current levels of the registry.
+func (h *LevelHandler) State() LevelState {
+	s := LevelState{Level: h.registry.Root(), Loggers: h.registry.Levels()}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, p := range h.pending {
+		if s.Expires == nil {
+			s.Expires = make(map[string]time.Time, len(h.pending))
+		}
+		s.Expires[name] = p.expires
+	}
+	return s
+}
+
+// Apply validates and applies c to the registry.
+func (h *LevelHandler) Apply(c LevelChange) error {
+	var ttl time.Duration
+	if c.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(c.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl %q: must be a positive duration", c.TTL)
+		}
+	}
+	var level mappers.Level
+	unset := c.Level == ""
+	if unset {
+		if c.Logger == "" {
+			return errors.New("the root level cannot be unset")
+		}
+	} else {
+		var err error
+		if level, err = mappers.ParseLevel(c.Level); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The level to restore is the one before the first pending change.
+	p := &pendingRestore{}
+	if prev, ok := h.pending[c.Logger]; ok {
+		prev.timer.Stop()
+		delete(h.pending, c.Logger)
+		p.level, p.unset = prev.level, prev.unset
+	} else {
+		p.level, p.unset = h.current(c.Logger)
+	}
+
+	if unset {
+		h.registry.Unset(c.Logger)
+	} else {
+		h.registry.SetLevel(c.Logger, level)
+	}
+
+	if ttl > 0 {
+		p.expires = time.Now().Add(ttl)
+		p.timer = time.AfterFunc(ttl, func() { h.restore(c.Logger, p) })
+		h.pending[c.Logger] = p
+	}
+	return nil
+}
+
+// current returns the level set for name, or unset when it has none.
+func (h *LevelHandler) current(name string) (level mappers.Level, unset bool) {
+	if name == "" {
+		return h.registry.Root(), false
+	}
+	level, ok := h.registry.Levels()[name]
+	return level, !ok
+}
+
+func (h *LevelHandler) restore(name string, p *pendingRestore) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// A later change may have replaced p while its timer was firing.
+	if h.pending[name] != p {
+		return
+	}
+	delete(h.pending, name)
+	if p.unset {
+		h.registry.Unset(name)
+	} else {
+		h.registry.SetLevel(name, p.level)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
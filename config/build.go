@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"io"
+	stdlog "log"
+	stdslog "log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/logrus"
+	"github.com/marcaudefroy/loggers/mappers/slog"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+	"github.com/marcaudefroy/loggers/output"
+	sirupsen "github.com/sirupsen/logrus"
+)
+
+// Logger is a Contextual logger built from a Config.
+// Its level can be changed at runtime, and Close releases its outputs.
+type Logger struct {
+	*mappers.LevelFilter
+	config  Config
+	closers []io.Closer
+}
+
+// Build validates c and builds the logger it describes.
+func (c Config) Build() (*Logger, error) {
+	c, err := c.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var writers []io.Writer
+	var closers []io.Closer
+	for _, o := range c.Outputs {
+		w, err := o.open()
+		if err != nil {
+			closeAll(closers)
+			return nil, err
+		}
+		writers = append(writers, w)
+		if closer, ok := w.(io.Closer); ok && w != os.Stderr && w != os.Stdout {
+			closers = append(closers, closer)
+		}
+	}
+	out := writers[0]
+	if len(writers) > 1 {
+		out = io.MultiWriter(writers...)
+	}
+
+	l := newBackend(c.Backend, c.Encoder, out)
+	if r := c.Redaction; r != nil {
+		redaction := mappers.Redaction{Keys: r.Keys, Replacement: r.Replacement}
+		for _, p := range r.Patterns {
+			re, _ := regexp.Compile(p)
+			redaction.Patterns = append(redaction.Patterns, re)
+		}
+		l = mappers.NewRedactingLogger(l, redaction)
+	}
+	if s := c.Sampling; s != nil {
+		l = mappers.NewSamplingLogger(l, time.Duration(s.Tick), s.Initial, s.Thereafter)
+	}
+	if c.Caller {
+		l = mappers.NewCallerLogger(l)
+	}
+	if len(c.Fields) > 0 {
+		keys := make([]string, 0, len(c.Fields))
+		for k := range c.Fields {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		fields := make([]any, 0, 2*len(keys))
+		for _, k := range keys {
+			fields = append(fields, k, c.Fields[k])
+		}
+		l = l.WithFields(fields...)
+	}
+	level, _ := mappers.ParseLevel(c.Level)
+
+	return &Logger{
+		LevelFilter: mappers.NewLevelFilter(l, mappers.NewLevelVar(level)),
+		config:      c,
+		closers:     closers,
+	}, nil
+}
+
+// Config returns the configuration of the logger with its defaults filled in
+// and its current level, so that it can be inspected or encoded back to JSON.
+func (l *Logger) Config() Config {
+	c := l.config
+	text, _ := l.Level().MarshalText()
+	c.Level = string(text)
+	return c
+}
+
+// Close closes the file and network outputs of the logger.
+func (l *Logger) Close() error {
+	return closeAll(l.closers)
+}
+
+func (o Output) open() (io.Writer, error) {
+	switch o.Type {
+	case "stdout":
+		return os.Stdout, nil
+	case "file":
+		perm, _ := o.perm()
+		opts := []output.FileOption{
+			output.MaxSize(o.MaxSize),
+			output.MaxBackups(o.MaxBackups),
+			output.MaxAge(time.Duration(o.MaxAge)),
+			output.RotateEvery(time.Duration(o.RotateEvery)),
+			output.Perm(perm),
+		}
+		if o.Compress {
+			opts = append(opts, output.Compress())
+		}
+		return output.NewFile(o.Path, opts...)
+	case "network":
+		return output.NewNetwork(o.Network, o.Address, time.Duration(o.Timeout)), nil
+	default:
+		return os.Stderr, nil
+	}
+}
+
+// newBackend returns a mapped logger letting every level through, filtering is left to the caller.
+func newBackend(backend, encoder string, out io.Writer) loggers.Contextual {
+	switch backend {
+	case "slog":
+		opts := &stdslog.HandlerOptions{Level: stdslog.LevelDebug}
+		if encoder == "json" {
+			return slog.NewLogger(stdslog.New(stdslog.NewJSONHandler(out, opts)))
+		}
+		return slog.NewLogger(stdslog.New(stdslog.NewTextHandler(out, opts)))
+	case "logrus":
+		l := sirupsen.New()
+		l.Out = out
+		l.Level = sirupsen.DebugLevel
+		if encoder == "json" {
+			l.Formatter = &sirupsen.JSONFormatter{}
+		}
+		return logrus.NewLogger(l)
+	default:
+		return stdlib.NewLogger(stdlog.New(out, "", stdlog.LstdFlags))
+	}
+}
+
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
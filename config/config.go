@@ -0,0 +1,241 @@
+// Package config builds Contextual loggers from a declarative, JSON serializable description.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Config describes a complete logger. The zero value is a valid Config
+// logging text at Info level to stderr with the stdlib backend.
+type Config struct {
+	// Backend is the mapped logger: stdlib (default), slog or logrus.
+	Backend string `json:"backend,omitempty"`
+	// Encoder is the entry format: text (default) or json. The stdlib backend only supports text.
+	Encoder string `json:"encoder,omitempty"`
+	// Level is the minimum level, info by default.
+	Level string `json:"level,omitempty"`
+	// Caller adds the location of the logging call as a caller field.
+	Caller bool `json:"caller,omitempty"`
+	// Outputs are the destinations of the entries, stderr when empty.
+	Outputs []Output `json:"outputs,omitempty"`
+	// Sampling limits repeated entries when set.
+	Sampling *Sampling `json:"sampling,omitempty"`
+	// Redaction hides sensitive values when set.
+	Redaction *Redaction `json:"redaction,omitempty"`
+	// Fields are added to every entry.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// Output describes a destination of the entries.
+type Output struct {
+	// Type is stderr, stdout, file or network.
+	Type string `json:"type"`
+
+	// Path is the file path of a file output.
+	Path string `json:"path,omitempty"`
+	// MaxSize is the size in bytes after which a file output is rotated, 0 disables rotation.
+	MaxSize int64 `json:"max_size,omitempty"`
+	// MaxBackups is the number of rotated files kept, 0 keeps them all.
+	MaxBackups int `json:"max_backups,omitempty"`
+	// MaxAge is the age after which rotated files are removed, 0 keeps them regardless of their age.
+	MaxAge Duration `json:"max_age,omitempty"`
+	// RotateEvery rotates a file output periodically, such as every "24h".
+	RotateEvery Duration `json:"rotate_every,omitempty"`
+	// Compress gzips the rotated files.
+	Compress bool `json:"compress,omitempty"`
+	// Perm is the octal permissions of a file output, "0644" by default.
+	Perm string `json:"perm,omitempty"`
+
+	// Network is the network of a network output: tcp, udp or unix.
+	Network string `json:"network,omitempty"`
+	// Address is the address of a network output.
+	Address string `json:"address,omitempty"`
+	// Timeout bounds dialing and writing of a network output.
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// Sampling limits repeated entries, see mappers.NewSamplingLogger.
+type Sampling struct {
+	// Tick is the sampling period, one second by default.
+	Tick Duration `json:"tick,omitempty"`
+	// Initial is the number of identical entries logged per tick.
+	Initial int `json:"initial"`
+	// Thereafter logs one out of Thereafter entries once Initial is reached, none if 0.
+	Thereafter int `json:"thereafter"`
+}
+
+// Redaction hides sensitive values, see mappers.Redaction.
+type Redaction struct {
+	// Keys are field keys, compared ignoring case, whose values are replaced.
+	Keys []string `json:"keys,omitempty"`
+	// Patterns are regular expressions replaced in messages and string field values.
+	Patterns []string `json:"patterns,omitempty"`
+	// Replacement defaults to mappers.RedactedValue.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// perm parses the octal permissions of a file output.
+func (o Output) perm() (os.FileMode, error) {
+	if o.Perm == "" {
+		return 0o644, nil
+	}
+	perm, err := strconv.ParseUint(o.Perm, 8, 32)
+	if err != nil || perm > 0o777 {
+		return 0, fmt.Errorf("invalid octal permissions %q", o.Perm)
+	}
+	return os.FileMode(perm), nil
+}
+
+// Duration is a time.Duration encoded as a string such as "1m30s" in JSON.
+type Duration time.Duration
+
+// MarshalText encodes d as a time.Duration string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText decodes a time.Duration string.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Parse decodes a JSON Config, rejecting unknown fields, and validates it.
+func Parse(data []byte) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("config: %w", err)
+	}
+	return c, c.Validate()
+}
+
+// String returns the JSON encoding of c.
+func (c Config) String() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	return string(b)
+}
+
+// Validate reports every invalid setting of c, each error naming the offending setting.
+func (c Config) Validate() error {
+	_, err := c.normalize()
+	return err
+}
+
+// normalize returns c with its defaults filled in, or the validation errors.
+func (c Config) normalize() (Config, error) {
+	var errs []error
+	fail := func(field, format string, v ...any) {
+		errs = append(errs, fmt.Errorf("config: %s: %s", field, fmt.Sprintf(format, v...)))
+	}
+
+	switch c.Backend {
+	case "":
+		c.Backend = "stdlib"
+	case "stdlib", "slog", "logrus":
+	default:
+		fail("backend", "unknown backend %q, expected stdlib, slog or logrus", c.Backend)
+	}
+	switch c.Encoder {
+	case "":
+		c.Encoder = "text"
+	case "text":
+	case "json":
+		if c.Backend == "stdlib" {
+			fail("encoder", "json is not supported by the stdlib backend")
+		}
+	default:
+		fail("encoder", "unknown encoder %q, expected text or json", c.Encoder)
+	}
+	if c.Level == "" {
+		c.Level = "info"
+	} else if _, err := mappers.ParseLevel(c.Level); err != nil {
+		fail("level", "%v", err)
+	}
+
+	if len(c.Outputs) == 0 {
+		c.Outputs = []Output{{Type: "stderr"}}
+	}
+	for i, o := range c.Outputs {
+		field := fmt.Sprintf("outputs[%d]", i)
+		switch o.Type {
+		case "stderr", "stdout":
+		case "file":
+			if o.Path == "" {
+				fail(field+".path", "required for a file output")
+			}
+			if o.MaxSize < 0 {
+				fail(field+".max_size", "must not be negative")
+			}
+			if o.MaxBackups < 0 {
+				fail(field+".max_backups", "must not be negative")
+			}
+			if o.MaxAge < 0 {
+				fail(field+".max_age", "must not be negative")
+			}
+			if o.RotateEvery < 0 {
+				fail(field+".rotate_every", "must not be negative")
+			}
+			if _, err := o.perm(); err != nil {
+				fail(field+".perm", "%v", err)
+			}
+		case "network":
+			switch o.Network {
+			case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram":
+			case "":
+				fail(field+".network", "required for a network output")
+			default:
+				fail(field+".network", "unknown network %q", o.Network)
+			}
+			if o.Address == "" {
+				fail(field+".address", "required for a network output")
+			}
+		case "":
+			fail(field+".type", "required")
+		default:
+			fail(field+".type", "unknown output type %q, expected stderr, stdout, file or network", o.Type)
+		}
+	}
+
+	if s := c.Sampling; s != nil {
+		sampling := *s
+		if sampling.Tick == 0 {
+			sampling.Tick = Duration(time.Second)
+		}
+		if sampling.Tick < 0 {
+			fail("sampling.tick", "must be positive")
+		}
+		if sampling.Initial < 0 {
+			fail("sampling.initial", "must not be negative")
+		}
+		if sampling.Thereafter < 0 {
+			fail("sampling.thereafter", "must not be negative")
+		}
+		c.Sampling = &sampling
+	}
+	if r := c.Redaction; r != nil {
+		for i, p := range r.Patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				fail(fmt.Sprintf("redaction.patterns[%d]", i), "%v", err)
+			}
+		}
+	}
+	return c, errors.Join(errs...)
+}
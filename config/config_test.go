@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+func TestConfigInterface(t *testing.T) {
+	var _ loggers.Contextual = &Logger{}
+	var _ mappers.LevelSetter = &Logger{}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	if _, err := Parse([]byte(`{"backend":"slog","colour":true}`)); err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Errorf("Parse must reject unknown fields, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Config{
+		Backend: "stdlib",
+		Encoder: "json",
+		Level:   "loud",
+		Outputs: []Output{{Type: "file", Perm: "rw"}, {Type: "network", Network: "tcp"}},
+		Sampling: &Sampling{
+			Initial: -1,
+		},
+		Redaction: &Redaction{Patterns: []string{"("}},
+	}
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("Validate must fail")
+	}
+	for _, expected := range []string{
+		"encoder: json is not supported by the stdlib backend",
+		"level: unknown log level",
+		"outputs[0].path: required",
+		"outputs[0].perm: invalid octal permissions",
+		"outputs[1].address: required",
+		"sampling.initial: must not be negative",
+		"redaction.patterns[0]:",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validation error %q does not contain %q", err, expected)
+		}
+	}
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("The zero Config must be valid, got %v", err)
+	}
+}
+
+func TestBuildFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	c, err := Parse([]byte(`{
+		"backend": "slog",
+		"encoder": "json",
+		"level": "info",
+		"outputs": [{"type": "file", "path": "` + path + `", "max_size": 1048576, "rotate_every": "24h", "compress": true, "perm": "0600"}],
+		"sampling": {"initial": 2, "thereafter": 0},
+		"redaction": {"keys": ["password"], "patterns": ["\\d{4}-\\d{4}"]},
+		"fields": {"service": "api"}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	l, err := c.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	l.Debug("hidden")
+	for i := 0; i < 3; i++ {
+		l.WithField("password", "secret").Infof("card %s", "1234-5678")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	b, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 sampled entries, got %d: %q", len(lines), b)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to decode entry: %v", err)
+	}
+	expected := map[string]any{
+		"msg":      "card " + mappers.RedactedValue,
+		"password": mappers.RedactedValue,
+		"service":  "api",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Field %s mismatch %v (actual) != %v (expected)", k, entry[k], v)
+		}
+	}
+}
+
+func TestBuildNetwork(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	l, err := Config{Outputs: []Output{{Type: "network", Network: "tcp", Address: ln.Addr().String()}}}.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	defer l.Close()
+	l.Warn("over the wire")
+
+	if line := <-received; !strings.Contains(line, "WARN  over the wire") {
+		t.Errorf("Received %q does not contain %q", line, "WARN  over the wire")
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	l, err := Config{Backend: "logrus", Sampling: &Sampling{Initial: 10}}.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	l.SetLevel(mappers.LevelError)
+
+	c, err := Parse([]byte(l.Config().String()))
+	if err != nil {
+		t.Fatalf("Parse of %s failed: %v", l.Config(), err)
+	}
+	if c.Level != "error" || c.Backend != "logrus" || c.Encoder != "text" || c.Sampling.Tick != Duration(time.Second) {
+		t.Errorf("Round tripped config mismatch: %s", c)
+	}
+}
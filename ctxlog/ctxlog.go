@@ -0,0 +1,117 @@
+// Package ctxlog carries loggers in contexts and correlates their entries with the
+// distributed trace of the context, adding trace_id, span_id and trace_flags fields.
+package ctxlog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/log"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx holding l, returned by FromContext.
+func NewContext(ctx context.Context, l loggers.Contextual) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Lookup returns the logger stored in ctx by NewContext, without trace correlation fields.
+func Lookup(ctx context.Context) (loggers.Contextual, bool) {
+	l, ok := ctx.Value(loggerKey{}).(loggers.Contextual)
+	return l, ok
+}
+
+// FromContext returns the logger of ctx, or the global logger if it has none,
+// correlated with the trace of ctx by DefaultCorrelator.
+func FromContext(ctx context.Context) loggers.Contextual {
+	l, ok := Lookup(ctx)
+	if !ok {
+		l = log.Default()
+	}
+	return DefaultCorrelator.Logger(ctx, l)
+}
+
+// Correlator adds the trace correlation fields of a context to loggers.
+// Its fields are not safe to change concurrently with its use, set them at initialization.
+type Correlator struct {
+	// Extractors are tried in order, the first finding a valid span context is used.
+	Extractors []Extractor
+	// RecordErrors records Error, Fatal and Panic entries as events of the span of the context,
+	// when the extractor which found it is an EventRecorder.
+	RecordErrors bool
+}
+
+// DefaultCorrelator is the Correlator of FromContext, extracting W3C traceparent span contexts.
+var DefaultCorrelator = &Correlator{Extractors: []Extractor{TraceParent}}
+
+// Logger returns l with the trace correlation fields of ctx, or l itself when ctx has no span context.
+func (c *Correlator) Logger(ctx context.Context, l loggers.Contextual) loggers.Contextual {
+	for _, e := range c.Extractors {
+		s, ok := e.Extract(ctx)
+		if !ok || !s.IsValid() {
+			continue
+		}
+		l = l.WithFields(s.Fields()...)
+		if r, ok := e.(EventRecorder); ok && c.RecordErrors {
+			l = mappers.NewContextualMap(&eventLogger{logger: l, ctx: ctx, recorder: r})
+		}
+		return l
+	}
+	return l
+}
+
+// eventLogger records Error entries and above as span events before logging them.
+type eventLogger struct {
+	logger   loggers.Contextual
+	ctx      context.Context
+	recorder EventRecorder
+	fields   []any
+}
+
+func (l *eventLogger) GetUnderlying() any {
+	return l.logger.GetUnderlying()
+}
+
+// LevelPrint is a Mapper method
+func (l *eventLogger) LevelPrint(lev mappers.Level, i ...any) {
+	if lev >= mappers.LevelError {
+		l.recorder.RecordEvent(l.ctx, lev, fmt.Sprint(i...), l.fields)
+	}
+	mappers.Dispatch(l.logger, lev, i...)
+}
+
+// LevelPrintf is a Mapper method
+func (l *eventLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	if lev >= mappers.LevelError {
+		l.recorder.RecordEvent(l.ctx, lev, fmt.Sprintf(format, i...), l.fields)
+	}
+	mappers.Dispatchf(l.logger, lev, format, i...)
+}
+
+// LevelPrintln is a Mapper method
+func (l *eventLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	if lev >= mappers.LevelError {
+		l.recorder.RecordEvent(l.ctx, lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"), l.fields)
+	}
+	mappers.Dispatchln(l.logger, lev, i...)
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *eventLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *eventLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := eventLogger{
+		logger:   l.logger.WithFields(fields...),
+		ctx:      l.ctx,
+		recorder: l.recorder,
+		fields:   append(l.fields[:len(l.fields):len(l.fields)], fields...),
+	}
+	return mappers.NewContextualMap(&nl)
+}
@@ -0,0 +1,131 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	stdlog "log"
+	"strings"
+	"testing"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/log"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newBufferedLog() (loggers.Contextual, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return stdlib.NewLogger(stdlog.New(&buf, "", 0)), &buf
+}
+
+func TestParseTraceParent(t *testing.T) {
+	s, err := ParseTraceParent(traceparent)
+	if err != nil {
+		t.Fatalf("ParseTraceParent failed: %v", err)
+	}
+	if !s.Sampled() || s.TraceID[0] != 0x4b || s.SpanID[7] != 0xb7 {
+		t.Errorf("SpanContext mismatch %+v", s)
+	}
+	if tp := s.TraceParent(); tp != traceparent {
+		t.Errorf("TraceParent mismatch %q (actual) != %q (expected)", tp, traceparent)
+	}
+	if _, err := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Errorf("ParseTraceParent of a future version failed: %v", err)
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	}
+	for _, tp := range invalid {
+		if _, err := ParseTraceParent(tp); err == nil {
+			t.Errorf("Expected an error for %q", tp)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	l, buf := newBufferedLog()
+	defer log.Replace(l)()
+
+	ctx := context.Background()
+	FromContext(ctx).Info("untraced")
+	ctx = WithTraceParent(ctx, traceparent)
+	FromContext(ctx).Info("traced")
+	FromContext(WithTraceParent(context.Background(), "invalid")).Info("invalid")
+
+	expected := "INFO  untraced\n" +
+		"INFO  traced [trace_id=4bf92f3577b34da6a3ce929d0e0e4736, span_id=00f067aa0ba902b7, trace_flags=01]\n" +
+		"INFO  invalid\n"
+	if buf.String() != expected {
+		t.Errorf("Output mismatch %q (actual) != %q (expected)", buf.String(), expected)
+	}
+
+	if _, ok := Lookup(ctx); ok {
+		t.Errorf("Unexpected logger in a context without one")
+	}
+	own, ownBuf := newBufferedLog()
+	FromContext(NewContext(ctx, own.WithField("request", 1))).Warn("own")
+	if !strings.HasPrefix(ownBuf.String(), "WARN  own [request=1, trace_id=4bf92f") {
+		t.Errorf("Output mismatch %q", ownBuf.String())
+	}
+}
+
+type event struct {
+	lev    mappers.Level
+	msg    string
+	fields []any
+}
+
+// recordingExtractor extracts a fixed span context and records events.
+type recordingExtractor struct {
+	span   SpanContext
+	events []event
+}
+
+func (e *recordingExtractor) Extract(ctx context.Context) (SpanContext, bool) {
+	return e.span, true
+}
+
+func (e *recordingExtractor) RecordEvent(ctx context.Context, lev mappers.Level, msg string, fields []any) {
+	e.events = append(e.events, event{lev, msg, fields})
+}
+
+func TestCorrelatorRecordErrors(t *testing.T) {
+	s, _ := ParseTraceParent(traceparent)
+	e := recordingExtractor{span: s}
+	c := Correlator{Extractors: []Extractor{TraceParent, &e}, RecordErrors: true}
+
+	l, buf := newBufferedLog()
+	cl := c.Logger(context.Background(), l)
+	cl.Info("not recorded")
+	cl.WithField("n", 1).Errorf("recorded %d", 1)
+	cl.Errorln("recorded", 2)
+
+	if len(e.events) != 2 {
+		t.Fatalf("Events mismatch %d (actual) != 2 (expected)", len(e.events))
+	}
+	if ev := e.events[0]; ev.lev != mappers.LevelError || ev.msg != "recorded 1" || len(ev.fields) != 2 {
+		t.Errorf("Event mismatch %+v", ev)
+	}
+	if ev := e.events[1]; ev.lev != mappers.LevelError || ev.msg != "recorded 2" {
+		t.Errorf("Event mismatch %+v", ev)
+	}
+	if n := strings.Count(buf.String(), "trace_id=4bf92f"); n != 3 {
+		t.Errorf("Traced lines mismatch %d (actual) != 3 (expected) in %q", n, buf.String())
+	}
+
+	c.RecordErrors = false
+	c.Logger(context.Background(), l).Error("not recorded")
+	if len(e.events) != 2 {
+		t.Errorf("Unexpected event recorded without RecordErrors")
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/marcaudefroy/loggers/ctxlog/otel
+
+go 1.24
+
+require (
+	github.com/marcaudefroy/loggers v1.0.0
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
+	github.com/sirupsen/logrus v1.6.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+)
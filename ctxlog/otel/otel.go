@@ -0,0 +1,75 @@
+// Package otel extracts the span context of OpenTelemetry spans for trace correlation,
+// and records error entries as events of the active span.
+//
+// It is a module of its own, so that the OpenTelemetry dependencies are only required by its users.
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// EventName is the name of the span events recording log entries.
+const EventName = "log"
+
+// Extractor extracts the span context of the OpenTelemetry span of a context.
+// It is an EventRecorder adding log entries as events of recording spans.
+var Extractor ctxlog.Extractor = extractor{}
+
+// Register adds Extractor to ctxlog.DefaultCorrelator, before the W3C traceparent extractor,
+// and enables recording error entries as span events if recordErrors is set.
+// It is meant to be called at initialization.
+func Register(recordErrors bool) {
+	c := ctxlog.DefaultCorrelator
+	c.Extractors = append([]ctxlog.Extractor{Extractor}, c.Extractors...)
+	c.RecordErrors = recordErrors
+}
+
+type extractor struct{}
+
+// Extract returns the span context of the span of ctx.
+func (extractor) Extract(ctx context.Context) (ctxlog.SpanContext, bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctxlog.SpanContext{}, false
+	}
+	return ctxlog.SpanContext{TraceID: sc.TraceID(), SpanID: sc.SpanID(), Flags: byte(sc.TraceFlags())}, true
+}
+
+// RecordEvent adds the entry as a "log" event of the span of ctx, if it is recording,
+// with its level, message and fields as attributes.
+func (extractor) RecordEvent(ctx context.Context, lev mappers.Level, msg string, fields []any) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	level, _ := lev.MarshalText()
+	attrs := make([]attribute.KeyValue, 0, len(fields)/2+2)
+	attrs = append(attrs, attribute.String("log.severity", string(level)), attribute.String("log.message", msg))
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		attrs = append(attrs, attributeOf(fmt.Sprint(fields[i]), fields[i+1]))
+	}
+	span.AddEvent(EventName, trace.WithAttributes(attrs...))
+}
+
+func attributeOf(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
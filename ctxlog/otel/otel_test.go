@@ -0,0 +1,67 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	stdlog "log"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+// recordingSpan is a recording span keeping its events.
+type recordingSpan struct {
+	noop.Span
+	sc     trace.SpanContext
+	events map[string][]attribute.KeyValue
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.sc }
+func (s *recordingSpan) IsRecording() bool              { return true }
+func (s *recordingSpan) AddEvent(name string, opts ...trace.EventOption) {
+	c := trace.NewEventConfig(opts...)
+	s.events[name] = c.Attributes()
+}
+
+func TestOTelCorrelation(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9},
+		SpanID:     trace.SpanID{0x00, 0xf0},
+		TraceFlags: trace.FlagsSampled,
+	})
+	span := recordingSpan{sc: sc, events: map[string][]attribute.KeyValue{}}
+	ctx := trace.ContextWithSpan(context.Background(), &span)
+
+	defer func(c ctxlog.Correlator) { *ctxlog.DefaultCorrelator = c }(*ctxlog.DefaultCorrelator)
+	Register(true)
+
+	var buf bytes.Buffer
+	l := ctxlog.FromContext(ctxlog.NewContext(ctx, stdlib.NewLogger(stdlog.New(&buf, "", 0))))
+	l.Info("info")
+	l.WithField("user", "bob").Error("failed")
+
+	expected := "trace_id=4bf90000000000000000000000000000, span_id=00f0000000000000, trace_flags=01"
+	if n := strings.Count(buf.String(), expected); n != 2 {
+		t.Errorf("Output mismatch %q (actual) != 2 lines with %q (expected)", buf.String(), expected)
+	}
+	attrs, ok := span.events[EventName]
+	if !ok {
+		t.Fatalf("Missing %q span event", EventName)
+	}
+	set := attribute.NewSet(attrs...)
+	for k, v := range map[string]string{"log.severity": "error", "log.message": "failed", "user": "bob"} {
+		if a, _ := set.Value(attribute.Key(k)); a.AsString() != v {
+			t.Errorf("Event attribute %s mismatch %q (actual) != %q (expected)", k, a.AsString(), v)
+		}
+	}
+
+	if _, ok := Extractor.Extract(context.Background()); ok {
+		t.Errorf("Unexpected span context without span")
+	}
+}
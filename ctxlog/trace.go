@@ -0,0 +1,117 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Field keys of the trace correlation fields.
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// SpanContext identifies a span of a distributed trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether both the trace and span IDs are set.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != [16]byte{} && s.SpanID != [8]byte{}
+}
+
+// Sampled reports whether the sampled flag is set.
+func (s SpanContext) Sampled() bool {
+	return s.Flags&1 == 1
+}
+
+// TraceParent returns s as a W3C traceparent header value.
+func (s SpanContext) TraceParent() string {
+	return "00-" + hex.EncodeToString(s.TraceID[:]) + "-" + hex.EncodeToString(s.SpanID[:]) + "-" + hex.EncodeToString([]byte{s.Flags})
+}
+
+// Fields returns the trace correlation fields of s: the hex encoded trace ID, span ID and flags.
+func (s SpanContext) Fields() []any {
+	return []any{
+		TraceIDKey, hex.EncodeToString(s.TraceID[:]),
+		SpanIDKey, hex.EncodeToString(s.SpanID[:]),
+		TraceFlagsKey, hex.EncodeToString([]byte{s.Flags}),
+	}
+}
+
+// ParseTraceParent parses a W3C traceparent header value: version-traceid-spanid-flags.
+// Versions above 00 are accepted as long as they start with these four fields.
+func ParseTraceParent(traceparent string) (SpanContext, error) {
+	var s SpanContext
+	traceparent = strings.TrimSpace(traceparent)
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return s, errors.New("ctxlog: malformed traceparent")
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return s, errors.New("ctxlog: invalid traceparent version")
+	}
+	var version, flags [1]byte
+	_, err1 := hex.Decode(version[:], []byte(parts[0]))
+	_, err2 := hex.Decode(s.TraceID[:], []byte(parts[1]))
+	_, err3 := hex.Decode(s.SpanID[:], []byte(parts[2]))
+	_, err4 := hex.Decode(flags[:], []byte(parts[3]))
+	if err := errors.Join(err1, err2, err3, err4); err != nil || strings.ToLower(traceparent) != traceparent {
+		return SpanContext{}, errors.New("ctxlog: traceparent is not lowercase hex")
+	}
+	s.Flags = flags[0]
+	if !s.IsValid() {
+		return SpanContext{}, errors.New("ctxlog: traceparent has a zero trace or span ID")
+	}
+	return s, nil
+}
+
+// Extractor extracts the span context of a context.
+type Extractor interface {
+	Extract(ctx context.Context) (SpanContext, bool)
+}
+
+// ExtractorFunc is an Extractor function.
+type ExtractorFunc func(ctx context.Context) (SpanContext, bool)
+
+// Extract calls f.
+func (f ExtractorFunc) Extract(ctx context.Context) (SpanContext, bool) {
+	return f(ctx)
+}
+
+// EventRecorder is implemented by extractors able to record log entries as events
+// of the span of a context.
+type EventRecorder interface {
+	RecordEvent(ctx context.Context, lev mappers.Level, msg string, fields []any)
+}
+
+type spanContextKey struct{}
+
+// WithSpanContext returns a copy of ctx holding s, as extracted by TraceParent.
+func WithSpanContext(ctx context.Context, s SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, s)
+}
+
+// WithTraceParent returns a copy of ctx holding the span context of a W3C traceparent
+// header value, typically of an incoming request. An invalid value returns ctx unchanged.
+func WithTraceParent(ctx context.Context, traceparent string) context.Context {
+	s, err := ParseTraceParent(traceparent)
+	if err != nil {
+		return ctx
+	}
+	return WithSpanContext(ctx, s)
+}
+
+// TraceParent extracts the span context set by WithTraceParent or WithSpanContext.
+var TraceParent Extractor = ExtractorFunc(func(ctx context.Context) (SpanContext, bool) {
+	s, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return s, ok && s.IsValid()
+})
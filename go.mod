@@ -2,9 +2,9 @@ module github.com/marcaudefroy/loggers
 
 go 1.24
 
-require github.com/sirupsen/logrus v1.6.0
-
 require (
-	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
-	golang.org/x/sys v0.0.0-20190422165155-953cdadca894 // indirect
+	github.com/sirupsen/logrus v1.6.0
+	golang.org/x/sys v0.0.0-20190422165155-953cdadca894
 )
+
+require github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
@@ -0,0 +1,37 @@
+package grpclogging
+
+import (
+	"google.golang.org/grpc/grpclog"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+// loggerV2 maps a Contextual logger to a grpclog.LoggerV2.
+type loggerV2 struct {
+	logger    loggers.Contextual
+	verbosity int
+}
+
+// NewLoggerV2 returns a grpclog.LoggerV2 logging to l, to be installed with grpclog.SetLoggerV2.
+// Verbose logs up to verbosity are enabled, see grpclog.LoggerV2.V.
+func NewLoggerV2(l loggers.Contextual, verbosity int) grpclog.LoggerV2 {
+	return &loggerV2{logger: l.WithField("system", "grpc"), verbosity: verbosity}
+}
+
+func (l *loggerV2) Info(args ...any)                    { l.logger.Info(args...) }
+func (l *loggerV2) Infoln(args ...any)                  { l.logger.Infoln(args...) }
+func (l *loggerV2) Infof(format string, args ...any)    { l.logger.Infof(format, args...) }
+func (l *loggerV2) Warning(args ...any)                 { l.logger.Warn(args...) }
+func (l *loggerV2) Warningln(args ...any)               { l.logger.Warnln(args...) }
+func (l *loggerV2) Warningf(format string, args ...any) { l.logger.Warnf(format, args...) }
+func (l *loggerV2) Error(args ...any)                   { l.logger.Error(args...) }
+func (l *loggerV2) Errorln(args ...any)                 { l.logger.Errorln(args...) }
+func (l *loggerV2) Errorf(format string, args ...any)   { l.logger.Errorf(format, args...) }
+func (l *loggerV2) Fatal(args ...any)                   { l.logger.Fatal(args...) }
+func (l *loggerV2) Fatalln(args ...any)                 { l.logger.Fatalln(args...) }
+func (l *loggerV2) Fatalf(format string, args ...any)   { l.logger.Fatalf(format, args...) }
+
+// V reports whether verbosity level lev is enabled.
+func (l *loggerV2) V(lev int) bool {
+	return lev <= l.verbosity
+}
@@ -0,0 +1,227 @@
+package grpclogging
+
+import (
+	"bytes"
+	"context"
+	stdlog "log"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor waits until the buffer contains s, and returns its content.
+func (b *syncBuffer) waitFor(t *testing.T, s string) string {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if out := b.String(); strings.Contains(out, s) {
+			return out
+		}
+	}
+	t.Fatalf("Output mismatch %q (actual) does not contain %q (expected)", b.String(), s)
+	return ""
+}
+
+func newBufferedLog() (loggers.Contextual, *syncBuffer) {
+	var buf syncBuffer
+	return stdlib.NewLogger(stdlog.New(&buf, "", 0)), &buf
+}
+
+// checkServer wraps a health server to check the logger of its calls context.
+type checkServer struct {
+	*health.Server
+}
+
+func (s checkServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	ctxlog.FromContext(ctx).Info("checking")
+	return s.Server.Check(ctx, req)
+}
+
+func newClient(t *testing.T, srvLog, cliLog loggers.Contextual, opts Options) healthpb.HealthClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(srvLog, opts)),
+		grpc.StreamInterceptor(StreamServerInterceptor(srvLog, opts)),
+	)
+	hs := health.NewServer()
+	hs.SetServingStatus("db", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, checkServer{hs})
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(cliLog, opts)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(cliLog, opts)),
+	)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnary(t *testing.T) {
+	srvLog, srvBuf := newBufferedLog()
+	cliLog, cliBuf := newBufferedLog()
+	client := newClient(t, srvLog, cliLog, Options{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "db"}); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	const method = "/grpc.health.v1.Health/Check"
+	out := srvBuf.waitFor(t, "INFO  "+method+" OK")
+	pattern := `INFO  checking \[method=` + method + `, kind=server, peer=\S+, deadline=\S+, trace_id=4bf92f3577b34da6a3ce929d0e0e4736`
+	if !regexp.MustCompile(pattern).MatchString(out) {
+		t.Errorf("Server output mismatch %q (actual) != %q (expected)", out, pattern)
+	}
+	pattern = `INFO  ` + method + ` OK \[.*, code=OK, duration=\S+\]`
+	if !regexp.MustCompile(pattern).MatchString(out) {
+		t.Errorf("Server output mismatch %q (actual) != %q (expected)", out, pattern)
+	}
+	out = cliBuf.waitFor(t, method+" OK")
+	if !strings.Contains(out, "kind=client, peer=passthrough:///bufnet") {
+		t.Errorf("Client output mismatch %q", out)
+	}
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	if err == nil {
+		t.Fatalf("Expected a NotFound error")
+	}
+	out = srvBuf.waitFor(t, "WARN  "+method+" NotFound")
+	if !strings.Contains(out, "code=NotFound") || !strings.Contains(out, "error=unknown service") {
+		t.Errorf("Server output mismatch %q", out)
+	}
+}
+
+func TestStream(t *testing.T) {
+	srvLog, srvBuf := newBufferedLog()
+	cliLog, cliBuf := newBufferedLog()
+	client := newClient(t, srvLog, cliLog, Options{Payloads: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "db"})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	cancel()
+	stream.Recv()
+
+	const method = "/grpc.health.v1.Health/Watch"
+	out := srvBuf.waitFor(t, "WARN  "+method+" Canceled")
+	if !strings.Contains(out, `DEBUG `+method+` received [method=`+method) || !strings.Contains(out, `payload={"service":"db"}`) {
+		t.Errorf("Server output mismatch %q", out)
+	}
+	if !strings.Contains(out, `DEBUG `+method+` sent [`) || !strings.Contains(out, `payload={"status":"SERVING"}`) {
+		t.Errorf("Server output mismatch %q", out)
+	}
+	out = cliBuf.waitFor(t, "WARN  "+method+" Canceled")
+	if !strings.Contains(out, `DEBUG `+method+` sent [`) || !strings.Contains(out, `DEBUG `+method+` received [`) {
+		t.Errorf("Client output mismatch %q", out)
+	}
+}
+
+func TestClientLoggerFromContext(t *testing.T) {
+	srvLog, _ := newBufferedLog()
+	cliLog, cliBuf := newBufferedLog()
+	client := newClient(t, srvLog, nil, Options{})
+
+	ctx := ctxlog.NewContext(context.Background(), cliLog.WithField("request_id", "r1"))
+	client.Check(ctx, &healthpb.HealthCheckRequest{Service: "db"})
+	if out := cliBuf.waitFor(t, "/grpc.health.v1.Health/Check OK"); !strings.Contains(out, "[request_id=r1, method=") {
+		t.Errorf("Client output mismatch %q", out)
+	}
+}
+
+func TestPayloadRendering(t *testing.T) {
+	opts := Options{
+		Payloads:       true,
+		MaxPayloadSize: 40,
+		Redaction:      &mappers.Redaction{Keys: []string{"Password"}, Patterns: []*regexp.Regexp{regexp.MustCompile(`\d{4}-\d{4}`)}},
+	}
+	opts.normalize()
+	c := call{opts: &opts}
+
+	msg := map[string]any{"password": "secret", "card": "1234-5678", "user": "bob"}
+	expected := `{"card":"[REDACTED]","password":"[REDACT...(truncated)`
+	if s := c.render(msg); s != expected {
+		t.Errorf("Payload mismatch %q (actual) != %q (expected)", s, expected)
+	}
+	if s := c.render(&healthpb.HealthCheckRequest{Service: "db"}); s != `{"service":"db"}` {
+		t.Errorf("Payload mismatch %q (actual) != %q (expected)", s, `{"service":"db"}`)
+	}
+}
+
+func TestCodeLevel(t *testing.T) {
+	expected := map[codes.Code]mappers.Level{
+		codes.OK:               mappers.LevelInfo,
+		codes.NotFound:         mappers.LevelWarn,
+		codes.Canceled:         mappers.LevelWarn,
+		codes.Internal:         mappers.LevelError,
+		codes.DeadlineExceeded: mappers.LevelError,
+	}
+	for code, lev := range expected {
+		if l := CodeLevel(code); l != lev {
+			t.Errorf("Level of %s mismatch %s (actual) != %s (expected)", code, l, lev)
+		}
+	}
+}
+
+func TestLoggerV2(t *testing.T) {
+	l, buf := newBufferedLog()
+	var g grpclog.LoggerV2 = NewLoggerV2(l, 1)
+	g.Infof("info %d", 1)
+	g.Warning("warning")
+	g.Errorln("error", 2)
+
+	expected := "INFO  info 1 [system=grpc]\nWARN  warning [system=grpc]\nERROR  error 2 [system=grpc]\n"
+	if buf.String() != expected {
+		t.Errorf("Output mismatch %q (actual) != %q (expected)", buf.String(), expected)
+	}
+	if !g.V(1) || g.V(2) {
+		t.Errorf("Verbosity mismatch V(1) %t, V(2) %t", g.V(1), g.V(2))
+	}
+}
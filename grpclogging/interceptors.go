@@ -0,0 +1,319 @@
+// Package grpclogging logs gRPC calls: interceptors giving each call a logger and logging its
+// completion, for servers and clients, and a grpclog.LoggerV2 backed by a Contextual logger.
+//
+// It is a module of its own, so that gRPC and protobuf are only required by its users.
+package grpclogging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/log"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Field keys of the call loggers and completion entries.
+const (
+	MethodKey   = "method"
+	KindKey     = "kind"
+	PeerKey     = "peer"
+	DeadlineKey = "deadline"
+	CodeKey     = "code"
+	DurationKey = "duration"
+	PayloadKey  = "payload"
+)
+
+// DefaultMaxPayloadSize is the default maximum size of logged payloads.
+const DefaultMaxPayloadSize = 1024
+
+// Options configures the interceptors. The zero value is usable.
+type Options struct {
+	// Payloads logs the messages sent and received at Debug level.
+	Payloads bool
+	// MaxPayloadSize truncates logged payloads, DefaultMaxPayloadSize by default.
+	MaxPayloadSize int
+	// Redaction hides payload fields whose name is one of its keys, and its patterns.
+	Redaction *mappers.Redaction
+	// Level returns the level of the completion entry of a call, CodeLevel by default.
+	Level func(codes.Code) mappers.Level
+}
+
+// CodeLevel returns Info for OK, Warn for codes caused by the caller and Error otherwise.
+func CodeLevel(code codes.Code) mappers.Level {
+	switch code {
+	case codes.OK:
+		return mappers.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.ResourceExhausted, codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return mappers.LevelWarn
+	}
+	return mappers.LevelError
+}
+
+func (o *Options) normalize() {
+	if o.MaxPayloadSize <= 0 {
+		o.MaxPayloadSize = DefaultMaxPayloadSize
+	}
+	if o.Level == nil {
+		o.Level = CodeLevel
+	}
+	if o.Redaction != nil && o.Redaction.Replacement == "" {
+		r := *o.Redaction
+		r.Replacement = mappers.RedactedValue
+		o.Redaction = &r
+	}
+}
+
+// call is an intercepted call.
+type call struct {
+	opts   *Options
+	logger loggers.Contextual
+	ctx    context.Context
+	method string
+	start  time.Time
+}
+
+// newCall returns a call with a child logger of l, the global logger if nil, stored in ctx.
+// The W3C traceparent metadata of incoming calls is added to ctx for trace correlation.
+func newCall(ctx context.Context, l loggers.Contextual, opts *Options, kind, method, peerAddr string) *call {
+	if l == nil {
+		l = log.Default()
+	}
+	fields := []any{MethodKey, method, KindKey, kind, PeerKey, peerAddr}
+	if d, ok := ctx.Deadline(); ok {
+		fields = append(fields, DeadlineKey, d.Format(time.RFC3339Nano))
+	}
+	l = l.WithFields(fields...)
+	if md, ok := metadata.FromIncomingContext(ctx); ok && kind == "server" {
+		if tp := md.Get("traceparent"); len(tp) > 0 {
+			ctx = ctxlog.WithTraceParent(ctx, tp[0])
+		}
+	}
+	ctx = ctxlog.NewContext(ctx, l)
+	return &call{opts: opts, logger: ctxlog.FromContext(ctx), ctx: ctx, method: method, start: time.Now()}
+}
+
+// payload logs a message sent or received when payloads are enabled.
+func (c *call) payload(direction string, msg any) {
+	if c.opts.Payloads {
+		c.logger.WithField(PayloadKey, c.render(msg)).Debugf("%s %s", c.method, direction)
+	}
+}
+
+// done logs the completion of the call.
+func (c *call) done(err error) {
+	code := status.Code(err)
+	l := c.logger.WithFields(CodeKey, code.String(), DurationKey, time.Since(c.start))
+	if err != nil && code != codes.OK {
+		l = l.WithField("error", status.Convert(err).Message())
+	}
+	mappers.Dispatchf(l, c.opts.Level(code), "%s %s", c.method, code)
+}
+
+// render returns msg as JSON, redacted and truncated.
+func (c *call) render(msg any) string {
+	var b []byte
+	var err error
+	if m, ok := msg.(proto.Message); ok {
+		b, err = protojson.Marshal(m)
+	} else {
+		b, err = json.Marshal(msg)
+	}
+	if err != nil {
+		return fmt.Sprintf("%v", msg)
+	}
+	if r := c.opts.Redaction; r != nil {
+		var v any
+		if json.Unmarshal(b, &v) == nil {
+			b, _ = json.Marshal(redact(r, v))
+		}
+	}
+	if len(b) > c.opts.MaxPayloadSize {
+		return string(b[:c.opts.MaxPayloadSize]) + "...(truncated)"
+	}
+	return string(b)
+}
+
+// redact replaces the values of the keys of r and its patterns in a decoded JSON value.
+func redact(r *mappers.Redaction, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			redacted := false
+			for _, key := range r.Keys {
+				if strings.EqualFold(key, k) {
+					v[k], redacted = r.Replacement, true
+					break
+				}
+			}
+			if !redacted {
+				v[k] = redact(r, e)
+			}
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = redact(r, e)
+		}
+	case string:
+		for _, p := range r.Patterns {
+			v = p.ReplaceAllString(v, r.Replacement)
+		}
+		return v
+	}
+	return v
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// UnaryServerInterceptor returns an interceptor giving each unary call a child logger of l
+// with the method, peer and deadline fields, retrieved from the handler context with
+// ctxlog.FromContext, and logging the completion of the call with its code and duration
+// at a level depending on the code. A nil l uses the global logger.
+func UnaryServerInterceptor(l loggers.Contextual, opts Options) grpc.UnaryServerInterceptor {
+	opts.normalize()
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		c := newCall(ctx, l, &opts, "server", info.FullMethod, peerAddr(ctx))
+		c.payload("received", req)
+		resp, err := handler(c.ctx, req)
+		if err == nil {
+			c.payload("sent", resp)
+		}
+		c.done(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(l loggers.Contextual, opts Options) grpc.StreamServerInterceptor {
+	opts.normalize()
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		c := newCall(ss.Context(), l, &opts, "server", info.FullMethod, peerAddr(ss.Context()))
+		err := handler(srv, &serverStream{ServerStream: ss, call: c})
+		c.done(err)
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	call *call
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.call.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.call.payload("sent", m)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.call.payload("received", m)
+	}
+	return err
+}
+
+// UnaryClientInterceptor returns an interceptor logging the completion of unary calls with
+// a child logger of the logger of the call context, or of l, or of the global logger,
+// with the method, target and deadline fields.
+func UnaryClientInterceptor(l loggers.Contextual, opts Options) grpc.UnaryClientInterceptor {
+	opts.normalize()
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		c := newCall(ctx, clientLogger(ctx, l), &opts, "client", method, cc.Target())
+		c.payload("sent", req)
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if err == nil {
+			c.payload("received", reply)
+		}
+		c.done(err)
+		return err
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of UnaryClientInterceptor.
+// The completion is logged when the stream ends, as seen by RecvMsg.
+func StreamClientInterceptor(l loggers.Contextual, opts Options) grpc.StreamClientInterceptor {
+	opts.normalize()
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		c := newCall(ctx, clientLogger(ctx, l), &opts, "client", method, cc.Target())
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			c.done(err)
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, call: c, unary: !desc.ServerStreams}, nil
+	}
+}
+
+// clientLogger returns the logger stored in ctx by ctxlog.NewContext, or l.
+func clientLogger(ctx context.Context, l loggers.Contextual) loggers.Contextual {
+	if cl, ok := ctxlog.Lookup(ctx); ok {
+		return cl
+	}
+	return l
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	call  *call
+	unary bool
+	ended bool
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.call.payload("sent", m)
+	} else if err != io.EOF {
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.call.payload("received", m)
+		if s.unary {
+			s.end(nil)
+		}
+	case err == io.EOF:
+		s.end(nil)
+	default:
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) end(err error) {
+	if !s.ended {
+		s.ended = true
+		s.call.done(err)
+	}
+}
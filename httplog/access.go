@@ -0,0 +1,261 @@
+package httplog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+// Apache access log formats.
+const (
+	CommonLogFormat   = `%h %l %u %t "%r" %>s %b`
+	CombinedLogFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// AccessRecord holds the data of a served request rendered by an AccessFormat.
+type AccessRecord struct {
+	Request        *http.Request
+	ResponseHeader http.Header
+	Status         int
+	Bytes          int64
+	// Time is the time the request was received.
+	Time     time.Time
+	Duration time.Duration
+}
+
+// AccessFormat renders access records in an Apache mod_log_config format. It supports the
+// directives %% %a %h %l %u %t %r %m %U %q %H %v %s %>s %b %B %D %T %{Name}i and %{Name}o.
+// Values taken from the request or response are escaped as Apache does: quotes and
+// backslashes are escaped with a backslash and non printable bytes as \xhh.
+type AccessFormat struct {
+	directives []directive
+	loc        *time.Location
+}
+
+// directive renders a part of a format, a literal when verb is 0.
+type directive struct {
+	verb    byte
+	literal string
+	name    string
+}
+
+// ParseAccessFormat parses an access log format such as CommonLogFormat.
+// Times are rendered in the local time zone, see In.
+func ParseAccessFormat(format string) (*AccessFormat, error) {
+	f := AccessFormat{loc: time.Local}
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return nil, errors.New("httplog: format ends with %")
+		}
+		if format[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+		d := directive{}
+		if format[i] == '{' {
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil, errors.New("httplog: unterminated %{ in format")
+			}
+			d.name = format[i+1 : i+end]
+			i += end + 1
+		} else if format[i] == '>' {
+			i++
+		}
+		if i == len(format) {
+			return nil, errors.New("httplog: format ends with an incomplete directive")
+		}
+		d.verb = format[i]
+		if !strings.ContainsRune("ahlutrmUqHvsbBDTio", rune(d.verb)) || (d.name == "") != (d.verb != 'i' && d.verb != 'o') {
+			return nil, fmt.Errorf("httplog: unsupported directive %%%c in format", d.verb)
+		}
+		if literal.Len() > 0 {
+			f.directives = append(f.directives, directive{literal: literal.String()})
+			literal.Reset()
+		}
+		f.directives = append(f.directives, d)
+	}
+	if literal.Len() > 0 {
+		f.directives = append(f.directives, directive{literal: literal.String()})
+	}
+	return &f, nil
+}
+
+// MustParseAccessFormat is like ParseAccessFormat but panics if the format is invalid.
+func MustParseAccessFormat(format string) *AccessFormat {
+	f, err := ParseAccessFormat(format)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// In returns a copy of f rendering times in loc.
+func (f *AccessFormat) In(loc *time.Location) *AccessFormat {
+	nf := *f
+	nf.loc = loc
+	return &nf
+}
+
+// Format renders r.
+func (f *AccessFormat) Format(r AccessRecord) string {
+	return string(f.Append(nil, r))
+}
+
+// Append appends the rendering of r to b.
+func (f *AccessFormat) Append(b []byte, r AccessRecord) []byte {
+	req := r.Request
+	for _, d := range f.directives {
+		switch d.verb {
+		case 0:
+			b = append(b, d.literal...)
+		case 'a', 'h':
+			host, _, err := net.SplitHostPort(req.RemoteAddr)
+			if err != nil {
+				host = req.RemoteAddr
+			}
+			b = appendEscaped(b, host)
+		case 'l':
+			b = append(b, '-')
+		case 'u':
+			b = appendEscaped(b, user(req))
+		case 't':
+			b = r.Time.In(f.loc).AppendFormat(append(b, '['), "02/Jan/2006:15:04:05 -0700")
+			b = append(b, ']')
+		case 'r':
+			b = appendEscaped(b, req.Method+" "+req.URL.RequestURI()+" "+req.Proto)
+		case 'm':
+			b = appendEscaped(b, req.Method)
+		case 'U':
+			b = appendEscaped(b, req.URL.EscapedPath())
+		case 'q':
+			if req.URL.RawQuery != "" {
+				b = appendEscaped(b, "?"+req.URL.RawQuery)
+			}
+		case 'H':
+			b = appendEscaped(b, req.Proto)
+		case 'v':
+			b = appendEscaped(b, req.Host)
+		case 's':
+			b = strconv.AppendInt(b, int64(r.Status), 10)
+		case 'b':
+			if r.Bytes == 0 {
+				b = append(b, '-')
+			} else {
+				b = strconv.AppendInt(b, r.Bytes, 10)
+			}
+		case 'B':
+			b = strconv.AppendInt(b, r.Bytes, 10)
+		case 'D':
+			b = strconv.AppendInt(b, r.Duration.Microseconds(), 10)
+		case 'T':
+			b = strconv.AppendInt(b, int64(r.Duration/time.Second), 10)
+		case 'i':
+			b = appendHeader(b, req.Header, d.name)
+		case 'o':
+			b = appendHeader(b, r.ResponseHeader, d.name)
+		}
+	}
+	return b
+}
+
+func user(r *http.Request) string {
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		return u
+	}
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		return r.URL.User.Username()
+	}
+	return "-"
+}
+
+func appendHeader(b []byte, h http.Header, name string) []byte {
+	v := strings.Join(h.Values(name), ", ")
+	if v == "" {
+		return append(b, '-')
+	}
+	return appendEscaped(b, v)
+}
+
+// appendEscaped appends s escaped as Apache does.
+func appendEscaped(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c >= 0x7f:
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// NewAccessLogHandler returns a handler serving requests with h and printing an access log
+// line per request to l, rendered by f. A nil f uses CommonLogFormat.
+func NewAccessLogHandler(l loggers.Standard, f *AccessFormat, h http.Handler) http.Handler {
+	if f == nil {
+		f = MustParseAccessFormat(CommonLogFormat)
+	}
+	return &accessHandler{print: func(line []byte) { l.Print(string(line)) }, format: f, next: h}
+}
+
+// NewAccessLogWriterHandler is like NewAccessLogHandler but writes the lines to w, as is.
+// Each line is written with a single Write call, serialized with the other lines.
+func NewAccessLogWriterHandler(w io.Writer, f *AccessFormat, h http.Handler) http.Handler {
+	if f == nil {
+		f = MustParseAccessFormat(CommonLogFormat)
+	}
+	var mu sync.Mutex
+	print := func(line []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(line, '\n'))
+	}
+	return &accessHandler{print: print, format: f, next: h}
+}
+
+type accessHandler struct {
+	print  func(line []byte)
+	format *AccessFormat
+	next   http.Handler
+}
+
+func (h *accessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rw := &responseWriter{ResponseWriter: w}
+	defer func() {
+		status := rw.Status()
+		if p := recover(); p != nil {
+			if !rw.wroteHeader {
+				status = http.StatusInternalServerError
+			}
+			defer panic(p)
+		}
+		h.print(h.format.Append(nil, AccessRecord{
+			Request:        r,
+			ResponseHeader: rw.Header(),
+			Status:         status,
+			Bytes:          rw.bytes,
+			Time:           start,
+			Duration:       time.Since(start),
+		}))
+	}()
+	h.next.ServeHTTP(rw, r)
+}
@@ -0,0 +1,194 @@
+// Package httplog logs HTTP requests: request scoped loggers and access logs for servers,
+// and a logging RoundTripper for clients.
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/log"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Field keys of the request scoped loggers and access log entries.
+const (
+	RequestIDKey  = "request_id"
+	MethodKey     = "method"
+	PathKey       = "path"
+	RemoteAddrKey = "remote_addr"
+	StatusKey     = "status"
+	BytesKey      = "bytes"
+	DurationKey   = "duration"
+	PanicKey      = "panic"
+	StackKey      = "stack"
+)
+
+// RequestIDHeader is the header holding the request ID, read from requests and set on responses.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs taken from requests.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID of a context of a request served by a Handler.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewHandler returns a handler serving requests with h, giving each a child logger of l with
+// the request ID, method, path and remote address fields. The child logger is stored in the
+// request context, to be retrieved with ctxlog.FromContext which also adds the fields of the
+// W3C traceparent of the request.
+//
+// Once served, an access log entry is logged with the status, bytes written and duration,
+// at Info level, Warn for 4xx and Error for 5xx statuses. Panics of h are logged with their
+// stack at Error level and answered with a 500 status, except http.ErrAbortHandler.
+// A nil l uses the global logger.
+func NewHandler(l loggers.Contextual, h http.Handler) http.Handler {
+	return &handler{logger: l, next: h}
+}
+
+// Middleware returns a function wrapping handlers with NewHandler.
+func Middleware(l loggers.Contextual) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return NewHandler(l, h)
+	}
+}
+
+type handler struct {
+	logger loggers.Contextual
+	next   http.Handler
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+
+	l := h.logger
+	if l == nil {
+		l = log.Default()
+	}
+	l = l.WithFields(RequestIDKey, id, MethodKey, r.Method, PathKey, r.URL.Path, RemoteAddrKey, r.RemoteAddr)
+	ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+	if tp := r.Header.Get("traceparent"); tp != "" {
+		ctx = ctxlog.WithTraceParent(ctx, tp)
+	}
+	ctx = ctxlog.NewContext(ctx, l)
+	rw := &responseWriter{ResponseWriter: w}
+
+	defer func() {
+		p := recover()
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		l := ctxlog.FromContext(ctx)
+		if p != nil {
+			l.WithFields(PanicKey, fmt.Sprint(p), StackKey, string(debug.Stack())).Errorf("panic serving %s %s", r.Method, r.URL.Path)
+			if !rw.wroteHeader {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			} else {
+				rw.status = http.StatusInternalServerError
+			}
+		}
+		status := rw.Status()
+		mappers.Dispatchf(l.WithFields(StatusKey, status, BytesKey, rw.bytes, DurationKey, time.Since(start)),
+			statusLevel(status), "%s %s %d", r.Method, r.URL.Path, status)
+	}()
+	h.next.ServeHTTP(rw, r.WithContext(ctx))
+}
+
+// statusLevel returns the level of the access log entry of a status.
+func statusLevel(status int) mappers.Level {
+	switch {
+	case status >= 500:
+		return mappers.LevelError
+	case status >= 400:
+		return mappers.LevelWarn
+	}
+	return mappers.LevelInfo
+}
+
+// validRequestID reports whether a request ID taken from a request is short and printable.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// responseWriter records the status and number of bytes of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// Status returns the response status, 200 if nothing was written.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = status >= 200
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher when the underlying writer does.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker when the underlying writer does.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
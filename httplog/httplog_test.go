@@ -0,0 +1,321 @@
+package httplog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+func newBufferedLog() (loggers.Contextual, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return stdlib.NewLogger(stdlog.New(&buf, "", 0)), &buf
+}
+
+func TestHandler(t *testing.T) {
+	l, buf := newBufferedLog()
+	var requestID string
+	h := NewHandler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = RequestID(r.Context())
+		ctxlog.FromContext(r.Context()).Info("handling")
+		w.Write([]byte("hello"))
+	}))
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/hello?q=1", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(rec, r)
+
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(requestID) {
+		t.Errorf("Generated request ID mismatch %q", requestID)
+	}
+	if id := rec.Header().Get(RequestIDHeader); id != requestID {
+		t.Errorf("Response request ID mismatch %q (actual) != %q (expected)", id, requestID)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Lines mismatch %q", buf.String())
+	}
+	fields := "request_id=" + requestID + ", method=GET, path=/hello, remote_addr=192.0.2.1:1234, trace_id=4bf92f3577b34da6a3ce929d0e0e4736"
+	if expected := "INFO  handling [" + fields; !strings.HasPrefix(lines[0], expected) {
+		t.Errorf("Line mismatch %q (actual) != %q... (expected)", lines[0], expected)
+	}
+	pattern := `^INFO  GET /hello 200 \[` + regexp.QuoteMeta(fields) + `.*, status=200, bytes=5, duration=\S+\]$`
+	if !regexp.MustCompile(pattern).MatchString(lines[1]) {
+		t.Errorf("Access log mismatch %q (actual) != %q (expected)", lines[1], pattern)
+	}
+}
+
+func TestHandlerRequestID(t *testing.T) {
+	l, buf := newBufferedLog()
+	h := Middleware(l)(http.NotFoundHandler())
+
+	for id, valid := range map[string]bool{"abc-123": true, "with space": false, strings.Repeat("x", 200): false} {
+		buf.Reset()
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/missing", nil)
+		r.Header.Set(RequestIDHeader, id)
+		h.ServeHTTP(rec, r)
+
+		if got := rec.Header().Get(RequestIDHeader); (got == id) != valid {
+			t.Errorf("Request ID %q mismatch %q (actual), valid %t (expected)", id, got, valid)
+		}
+		if !strings.HasPrefix(buf.String(), "WARN  POST /missing 404 [request_id=") {
+			t.Errorf("Access log mismatch %q", buf.String())
+		}
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	l, buf := newBufferedLog()
+	h := NewHandler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Status mismatch %d (actual) != %d (expected)", rec.Code, http.StatusInternalServerError)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "ERROR panic serving GET /panic [") || !strings.Contains(out, "panic=boom, stack=goroutine") {
+		t.Errorf("Panic log mismatch %q", out)
+	}
+	if !strings.Contains(out, "ERROR GET /panic 500 [") {
+		t.Errorf("Access log mismatch %q", out)
+	}
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("Recovered mismatch %v (actual) != %v (expected)", p, http.ErrAbortHandler)
+		}
+	}()
+	NewHandler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	l, _ := newBufferedLog()
+	rec := httptest.NewRecorder()
+	NewHandler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !rec.Flushed {
+		t.Errorf("Expected the response to be flushed")
+	}
+}
+
+func TestAccessFormat(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/a%20b?q=\"x\"", nil)
+	r.RemoteAddr = "203.0.113.7:5555"
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("Referer", "http://example.com/\"start\"")
+	r.Header.Set("User-Agent", "agent\\1\x01")
+	rec := AccessRecord{
+		Request:        r,
+		ResponseHeader: http.Header{"Content-Type": {"text/plain"}},
+		Status:         200,
+		Bytes:          2326,
+		Time:           time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC),
+		Duration:       1500 * time.Millisecond,
+	}
+	tz := time.FixedZone("", -7*3600)
+
+	formats := []struct {
+		format   *AccessFormat
+		expected string
+	}{
+		{MustParseAccessFormat(CommonLogFormat).In(tz),
+			`203.0.113.7 - frank [10/Oct/2000:13:55:36 -0700] "GET /a%20b?q=\"x\" HTTP/1.1" 200 2326`},
+		{MustParseAccessFormat(CombinedLogFormat).In(time.UTC),
+			`203.0.113.7 - frank [10/Oct/2000:20:55:36 +0000] "GET /a%20b?q=\"x\" HTTP/1.1" 200 2326 ` +
+				`"http://example.com/\"start\"" "agent\\1\x01"`},
+		{MustParseAccessFormat(`%m %U%q %H %v %s %B %D %T %{Content-Type}o %{X-Missing}i 100%%`),
+			`GET /a%20b?q=\"x\" HTTP/1.1 example.com 200 2326 1500000 1 text/plain - 100%`},
+	}
+	for _, f := range formats {
+		if s := f.format.Format(rec); s != f.expected {
+			t.Errorf("Format mismatch %q (actual) != %q (expected)", s, f.expected)
+		}
+	}
+
+	rec.Bytes = 0
+	if s := MustParseAccessFormat("%b %B").Format(rec); s != "- 0" {
+		t.Errorf("Format of empty bytes mismatch %q (actual) != %q (expected)", s, "- 0")
+	}
+
+	for _, invalid := range []string{"%", "%{Referer", "%z", "%{X}s", "%i", "%>"} {
+		if _, err := ParseAccessFormat(invalid); err == nil {
+			t.Errorf("Expected an error for format %q", invalid)
+		}
+	}
+}
+
+func TestAccessLogHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	var buf bytes.Buffer
+	NewAccessLogWriterHandler(&buf, nil, h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/item", nil))
+	pattern := `^192\.0\.2\.1 - - \[\d\d/\w{3}/\d{4}:\d\d:\d\d:\d\d [-+]\d{4}\] "PUT /item HTTP/1\.1" 201 7\n$`
+	if !regexp.MustCompile(pattern).MatchString(buf.String()) {
+		t.Errorf("Access log mismatch %q (actual) != %q (expected)", buf.String(), pattern)
+	}
+
+	l, lbuf := newBufferedLog()
+	f := MustParseAccessFormat(`"%r" %>s %b`)
+	NewAccessLogHandler(l, f, h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if expected := "INFO  \"GET / HTTP/1.1\" 201 7\n"; lbuf.String() != expected {
+		t.Errorf("Access log mismatch %q (actual) != %q (expected)", lbuf.String(), expected)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=1")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write(append([]byte("echo "), body...))
+	}))
+	defer srv.Close()
+
+	l, buf := newBufferedLog()
+	client := &http.Client{Transport: NewTransport(nil, l, TransportOptions{Headers: true, Body: true, MaxBodySize: 8})}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/items?token=abc&page=2", strings.NewReader("hello world"))
+	req.Header.Set("Authorization", "Bearer abc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "echo hello world" {
+		t.Errorf("Body mismatch %q (actual) != %q (expected)", body, "echo hello world")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Lines mismatch %q", buf.String())
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+	pattern := `^INFO  POST ` + regexp.QuoteMeta(host) + ` 200 \[method=POST, url=` +
+		regexp.QuoteMeta(srv.URL+"/items?token=[REDACTED]&page=2") + `, status=200, duration=\S+\]$`
+	if !regexp.MustCompile(pattern).MatchString(lines[0]) {
+		t.Errorf("Line mismatch %q (actual) != %q (expected)", lines[0], pattern)
+	}
+	for _, expected := range []string{"DEBUG POST " + host + " 200 details", "Authorization:[REDACTED]", "Set-Cookie:[REDACTED]",
+		"request_body=hello wo...(truncated)", "response_body=echo hel...(truncated)"} {
+		if !strings.Contains(lines[1], expected) {
+			t.Errorf("Line mismatch %q (actual) does not contain %q (expected)", lines[1], expected)
+		}
+	}
+}
+
+func TestTransportStreamedBody(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	l, buf := newBufferedLog()
+	client := &http.Client{Transport: NewTransport(nil, l, TransportOptions{Body: true})}
+	done := make(chan *http.Response)
+	go func() {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+		}
+		done <- resp
+	}()
+	var resp *http.Response
+	select {
+	case resp = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("RoundTrip must not wait for the streamed body")
+	}
+	if resp == nil {
+		return
+	}
+	event := make([]byte, len("data: 1\n\n"))
+	io.ReadFull(resp.Body, event)
+	if strings.Contains(buf.String(), "details") {
+		t.Errorf("Details must be logged when the body is closed, got %q", buf.String())
+	}
+	resp.Body.Close()
+	if !strings.Contains(buf.String(), `details [method=GET`) || !strings.Contains(buf.String(), "response_body=data: 1") {
+		t.Errorf("Details mismatch %q", buf.String())
+	}
+
+	// Without Debug entries, no details are captured nor logged.
+	buf.Reset()
+	client = &http.Client{Transport: NewTransport(nil, mappers.NewLevelFilter(l, mappers.NewLevelVar(mappers.LevelInfo)), TransportOptions{Body: true})}
+	go func() {
+		resp, _ := client.Get(srv.URL)
+		done <- resp
+	}()
+	select {
+	case resp = <-done:
+		resp.Body.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatalf("RoundTrip must not wait for the streamed body")
+	}
+	if strings.Contains(buf.String(), "details") {
+		t.Errorf("Unexpected details at Info level %q", buf.String())
+	}
+}
+
+func TestTransportRetriesAndErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	l, buf := newBufferedLog()
+	client := &http.Client{Transport: NewTransport(nil, nil, TransportOptions{})}
+
+	ctx := WithRetryCounter(ctxlog.NewContext(context.Background(), l.WithField("request_id", "r1")))
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/missing", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	srv.Close()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatalf("Expected an error from a closed server")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Lines mismatch %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "WARN  GET ") || !strings.Contains(lines[0], "[request_id=r1, method=GET") || strings.Contains(lines[0], "retries") {
+		t.Errorf("Line mismatch %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "/missing, retries=1, status=404") {
+		t.Errorf("Line mismatch %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "ERROR GET ") || !strings.Contains(lines[2], "retries=2, duration=") || !strings.Contains(lines[2], "error=") {
+		t.Errorf("Line mismatch %q", lines[2])
+	}
+}
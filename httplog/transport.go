@@ -0,0 +1,241 @@
+package httplog
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/log"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Field keys of the outbound request entries, in addition to MethodKey, StatusKey and DurationKey.
+const (
+	URLKey             = "url"
+	RetriesKey         = "retries"
+	ErrorKey           = "error"
+	RequestHeadersKey  = "request_headers"
+	RequestBodyKey     = "request_body"
+	ResponseHeadersKey = "response_headers"
+	ResponseBodyKey    = "response_body"
+)
+
+// DefaultMaxBodySize is the default maximum size of logged bodies.
+const DefaultMaxBodySize = 1024
+
+// DefaultRedactedQuery are the query parameters redacted by default.
+var DefaultRedactedQuery = []string{"access_token", "api_key", "apikey", "code", "key", "password",
+	"secret", "sig", "signature", "token", "x-amz-credential", "x-amz-signature"}
+
+// DefaultRedactedHeaders are the headers redacted by default.
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie"}
+
+// TransportOptions configures NewTransport. The zero value is usable.
+type TransportOptions struct {
+	// RedactedQuery are the query parameters, compared ignoring case, whose values are redacted
+	// from logged URLs, DefaultRedactedQuery by default. The URL password is always redacted.
+	RedactedQuery []string
+	// Headers logs the request and response headers at Debug level.
+	Headers bool
+	// RedactedHeaders are the headers whose values are redacted, DefaultRedactedHeaders by default.
+	RedactedHeaders []string
+	// Body logs the beginning of the request and response bodies at Debug level.
+	// The response body is captured as it is read, and the entry logged when it is closed.
+	Body bool
+	// MaxBodySize is the maximum size of logged bodies, DefaultMaxBodySize by default.
+	MaxBodySize int
+}
+
+// NewTransport returns a RoundTripper sending requests with rt, http.DefaultTransport if nil,
+// and logging them with their method, redacted URL, status and duration, at a level following
+// the status class, or at Error level when they fail. The logger of the request context,
+// stored by ctxlog.NewContext, is used if any, else l, else the global logger.
+// Retries of a request are counted when its context comes from WithRetryCounter.
+func NewTransport(rt http.RoundTripper, l loggers.Contextual, opts TransportOptions) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts.RedactedQuery == nil {
+		opts.RedactedQuery = DefaultRedactedQuery
+	}
+	if opts.RedactedHeaders == nil {
+		opts.RedactedHeaders = DefaultRedactedHeaders
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	return &transport{next: rt, logger: l, opts: opts}
+}
+
+type retryCounterKey struct{}
+
+// WithRetryCounter returns a copy of ctx counting the requests made with it by a transport
+// returned by NewTransport, so that the attempts after the first are logged as retries.
+func WithRetryCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryCounterKey{}, new(atomic.Int32))
+}
+
+type transport struct {
+	next   http.RoundTripper
+	logger loggers.Contextual
+	opts   TransportOptions
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	l, ok := ctxlog.Lookup(r.Context())
+	if !ok {
+		if l = t.logger; l == nil {
+			l = log.Default()
+		}
+	}
+	// The level is only known before fields are added.
+	debug := debugEnabled(l)
+	l = ctxlog.DefaultCorrelator.Logger(r.Context(), l)
+	fields := []any{MethodKey, r.Method, URLKey, t.redactURL(r)}
+	if c, ok := r.Context().Value(retryCounterKey{}).(*atomic.Int32); ok {
+		if retries := c.Add(1) - 1; retries > 0 {
+			fields = append(fields, RetriesKey, int(retries))
+		}
+	}
+	l = l.WithFields(fields...)
+
+	var reqBody *prefixWriter
+	if t.opts.Body && debug && r.Body != nil && r.Body != http.NoBody {
+		reqBody = &prefixWriter{max: t.opts.MaxBodySize}
+		r = r.Clone(r.Context())
+		r.Body = &teeBody{Reader: io.TeeReader(r.Body, reqBody), Closer: r.Body}
+	}
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		l.WithFields(DurationKey, time.Since(start), ErrorKey, err.Error()).Errorf("%s %s failed", r.Method, r.URL.Host)
+		return resp, err
+	}
+
+	dl := l.WithFields(StatusKey, resp.StatusCode, DurationKey, time.Since(start))
+	mappers.Dispatchf(dl, statusLevel(resp.StatusCode), "%s %s %d", r.Method, r.URL.Host, resp.StatusCode)
+	if (!t.opts.Headers && !t.opts.Body) || !debug {
+		return resp, nil
+	}
+	var details []any
+	if t.opts.Headers {
+		details = append(details, RequestHeadersKey, t.redactHeaders(r.Header), ResponseHeadersKey, t.redactHeaders(resp.Header))
+	}
+	logDetails := func(details ...any) {
+		dl.WithFields(details...).Debugf("%s %s %d details", r.Method, r.URL.Host, resp.StatusCode)
+	}
+	if !t.opts.Body {
+		logDetails(details...)
+		return resp, nil
+	}
+	if reqBody != nil {
+		details = append(details, RequestBodyKey, reqBody.String())
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		logDetails(append(details, ResponseBodyKey, "")...)
+		return resp, nil
+	}
+	// The response body is captured while the caller reads it, so that streamed responses are not
+	// delayed, and the details are logged when it is closed.
+	respBody := &prefixWriter{max: t.opts.MaxBodySize}
+	resp.Body = &closeHookBody{
+		teeBody: teeBody{Reader: io.TeeReader(resp.Body, respBody), Closer: resp.Body},
+		hook:    func() { logDetails(append(details, ResponseBodyKey, respBody.String())...) },
+	}
+	return resp, nil
+}
+
+// debugEnabled reports whether l logs Debug entries, as far as its minimum level is known.
+func debugEnabled(l loggers.Contextual) bool {
+	if lv, ok := l.(mappers.Leveler); ok {
+		return lv.Level() <= mappers.LevelDebug
+	}
+	return true
+}
+
+// redactURL returns the URL of r without password and with the values of the redacted query parameters replaced.
+func (t *transport) redactURL(r *http.Request) string {
+	u := *r.URL
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, p := range params {
+			name, _, _ := strings.Cut(p, "=")
+			for _, redacted := range t.opts.RedactedQuery {
+				if strings.EqualFold(name, redacted) {
+					params[i] = name + "=" + mappers.RedactedValue
+					break
+				}
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.Redacted()
+}
+
+func (t *transport) redactHeaders(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, v := range h {
+		m[k] = strings.Join(v, ", ")
+		for _, redacted := range t.opts.RedactedHeaders {
+			if strings.EqualFold(k, redacted) {
+				m[k] = mappers.RedactedValue
+				break
+			}
+		}
+	}
+	return m
+}
+
+// teeBody is a body reading from Reader and closed by Closer.
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// closeHookBody is a teeBody calling hook when first closed.
+type closeHookBody struct {
+	teeBody
+	once sync.Once
+	hook func()
+}
+
+func (b *closeHookBody) Close() error {
+	err := b.teeBody.Close()
+	b.once.Do(b.hook)
+	return err
+}
+
+// prefixWriter keeps the first max bytes written, and whether more were written.
+// The request body may still be written by the transport once the response is received,
+// and the response body read by another goroutine than the one closing it.
+type prefixWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	max  int
+	more bool
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := min(len(p), w.max-len(w.buf))
+	w.buf = append(w.buf, p[:n]...)
+	w.more = w.more || n < len(p)
+	return len(p), nil
+}
+
+func (w *prefixWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.more {
+		return string(w.buf) + "...(truncated)"
+	}
+	return string(w.buf)
+}
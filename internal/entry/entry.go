@@ -0,0 +1,123 @@
+// Package entry provides a decorator capturing log entries so that they can be logged later.
+package entry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// MaxMessageSize bounds the size of a captured message, longer ones are truncated.
+const MaxMessageSize = 8 << 10
+
+// Entry is a captured log entry.
+type Entry struct {
+	Time    time.Time
+	Level   mappers.Level
+	Message string
+	// Fields are the key/value pairs of the logger the entry was logged with.
+	Fields []any
+
+	logger loggers.Contextual
+}
+
+// Emit logs the entry to the logger it was captured from, with the extra fields.
+func (e Entry) Emit(fields ...any) {
+	l := e.logger
+	if len(fields) > 0 {
+		l = l.WithFields(fields...)
+	}
+	mappers.Dispatch(l, e.Level, e.Message)
+}
+
+// Logger returns the logger the entry was captured from.
+func (e Entry) Logger() loggers.Contextual {
+	return e.logger
+}
+
+// FieldMap returns the fields as a map with string keys.
+func (e Entry) FieldMap() map[string]any {
+	if len(e.Fields) < 2 {
+		return nil
+	}
+	m := make(map[string]any, len(e.Fields)/2)
+	for i := 0; i+1 < len(e.Fields); i = i + 2 {
+		m[fmt.Sprint(e.Fields[i])] = e.Fields[i+1]
+	}
+	return m
+}
+
+// Capturer decides which entries are captured and receives them.
+type Capturer interface {
+	// Capture reports whether an entry of level lev must be captured rather than logged.
+	Capture(lev mappers.Level) bool
+	// Captured receives a captured entry.
+	Captured(e Entry)
+	// Logging is called before an entry which is not captured is logged.
+	Logging(lev mappers.Level)
+}
+
+// NewLogger returns a Contextual logger passing the entries to l unless c captures them.
+func NewLogger(l loggers.Contextual, c Capturer) loggers.Contextual {
+	return mappers.NewContextualMap(&capturingLogger{logger: l, capturer: c})
+}
+
+type capturingLogger struct {
+	logger   loggers.Contextual
+	fields   []any
+	capturer Capturer
+}
+
+func (c *capturingLogger) GetUnderlying() any {
+	return c.logger.GetUnderlying()
+}
+
+func (c *capturingLogger) capture(lev mappers.Level, message string) {
+	if len(message) > MaxMessageSize {
+		message = strings.ToValidUTF8(message[:MaxMessageSize], "")
+	}
+	c.capturer.Captured(Entry{Time: time.Now(), Level: lev, Message: message, Fields: c.fields, logger: c.logger})
+}
+
+func (c *capturingLogger) LevelPrint(lev mappers.Level, v ...any) {
+	if c.capturer.Capture(lev) {
+		c.capture(lev, fmt.Sprint(v...))
+		return
+	}
+	c.capturer.Logging(lev)
+	mappers.Dispatch(c.logger, lev, v...)
+}
+
+func (c *capturingLogger) LevelPrintf(lev mappers.Level, format string, v ...any) {
+	if c.capturer.Capture(lev) {
+		c.capture(lev, fmt.Sprintf(format, v...))
+		return
+	}
+	c.capturer.Logging(lev)
+	mappers.Dispatchf(c.logger, lev, format, v...)
+}
+
+func (c *capturingLogger) LevelPrintln(lev mappers.Level, v ...any) {
+	if c.capturer.Capture(lev) {
+		c.capture(lev, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+		return
+	}
+	c.capturer.Logging(lev)
+	mappers.Dispatchln(c.logger, lev, v...)
+}
+
+func (c *capturingLogger) WithField(key string, value any) loggers.Contextual {
+	return c.WithFields(key, value)
+}
+
+func (c *capturingLogger) WithFields(fields ...any) loggers.Contextual {
+	nc := capturingLogger{
+		logger:   c.logger.WithFields(fields...),
+		fields:   append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		capturer: c.capturer,
+	}
+	return mappers.NewContextualMap(&nc)
+}
@@ -0,0 +1,110 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/config"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+// Environment variables configuring the default global logger.
+const (
+	// EnvBackend selects the mapped logger: stdlib (default), slog, logrus or json.
+	// json is a shorthand for the slog backend with the json format.
+	EnvBackend = "LOGGERS_BACKEND"
+	// EnvFormat selects the output format: text (default) or json. The stdlib backend only supports text.
+	EnvFormat = "LOGGERS_FORMAT"
+	// EnvLevel sets the minimum levels as named.Registry directives, e.g. "info,db=debug,http.client=warn".
+	// Levels are debug (default), info, warn, error, fatal or panic.
+	EnvLevel = "LOGGERS_LEVEL"
+	// EnvOutput selects the output: stderr (default), stdout or a file path, opened in append mode.
+	EnvOutput = "LOGGERS_OUTPUT"
+	// EnvCaller adds the location of the logging call to every entry when set to a true boolean.
+	EnvCaller = "LOGGERS_CALLER"
+)
+
+// defaultLogger is the logger built from the environment, installed at first use
+// of the global logger or when SetLogger is called with nil.
+var defaultLogger = sync.OnceValue(func() loggers.Contextual {
+	l, err := NewFromEnv()
+	if err != nil {
+		l.Warnf("Invalid logging environment, using defaults where needed: %v", err)
+	}
+	return l
+})
+
+// NewFromEnv returns a Contextual logger configured by the LOGGERS_* environment variables.
+// Invalid values are reported in the returned error and replaced by their default,
+// so the returned logger is always usable.
+// The logger is the root *named.Logger of named.DefaultRegistry, whose levels are replaced
+// by the ones of LOGGERS_LEVEL when it is set and valid.
+func NewFromEnv() (loggers.Contextual, error) {
+	var errs []error
+	// Levels are enforced by the registry, so the built logger lets everything through.
+	c := config.Config{Level: "debug"}
+
+	c.Backend = strings.ToLower(os.Getenv(EnvBackend))
+	c.Encoder = strings.ToLower(os.Getenv(EnvFormat))
+	if c.Backend == "json" {
+		c.Backend, c.Encoder = "slog", "json"
+	}
+	switch c.Backend {
+	case "", "stdlib", "slog", "logrus":
+	default:
+		errs = append(errs, fmt.Errorf("%s: unknown backend %q", EnvBackend, c.Backend))
+		c.Backend = ""
+	}
+	switch c.Encoder {
+	case "", "text":
+	case "json":
+		if c.Backend == "" || c.Backend == "stdlib" {
+			errs = append(errs, fmt.Errorf("%s: json format is not supported by the stdlib backend", EnvFormat))
+			c.Encoder = ""
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s: unknown format %q", EnvFormat, c.Encoder))
+		c.Encoder = ""
+	}
+
+	if v := os.Getenv(EnvLevel); v != "" {
+		if err := named.DefaultRegistry.SetDirectives(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", EnvLevel, err))
+		}
+	}
+
+	switch v := os.Getenv(EnvOutput); v {
+	case "", "stderr":
+	case "stdout":
+		c.Outputs = []config.Output{{Type: "stdout"}}
+	default:
+		c.Outputs = []config.Output{{Type: "file", Path: v}}
+	}
+
+	if v := os.Getenv(EnvCaller); v != "" {
+		var err error
+		if c.Caller, err = strconv.ParseBool(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", EnvCaller, err))
+		}
+	}
+
+	// The settings other than the output are validated on their own, so that a Build error
+	// is only reported as an EnvOutput error when the output caused it.
+	if err := (config.Config{Backend: c.Backend, Encoder: c.Encoder, Level: c.Level}).Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("%s, %s: %w", EnvBackend, EnvFormat, err))
+		c.Backend, c.Encoder = "", ""
+	}
+
+	l, err := c.Build()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", EnvOutput, err))
+		c.Outputs = nil
+		l, _ = c.Build()
+	}
+	return named.New(l, named.DefaultRegistry), errors.Join(errs...)
+}
@@ -1,132 +1,183 @@
 package log
 
 import (
+	"sync/atomic"
+
 	"github.com/marcaudefroy/loggers"
-	"github.com/marcaudefroy/loggers/mappers/stdlib"
+	"github.com/marcaudefroy/loggers/named"
 )
 
-// Logger is an Contextual logger interface.
-var Logger loggers.Contextual
+// global holds the Contextual logger used by the package level functions.
+// It is only ever accessed atomically so that it can be swapped at runtime.
+var global atomic.Pointer[holder]
+
+// holder boxes the logger interface so it can be stored in an atomic.Pointer.
+type holder struct {
+	logger loggers.Contextual
+}
+
+// Default returns the Contextual logger currently used by the package level functions.
+// On first use, the global logger is built from the environment, see NewFromEnv.
+func Default() loggers.Contextual {
+	if h := global.Load(); h != nil {
+		return h.logger
+	}
+	global.CompareAndSwap(nil, newHolder(nil))
+	return global.Load().logger
+}
+
+// SetLogger replaces the global logger. It is safe to call concurrently with logging.
+// A nil logger restores the default logger built from the environment.
+func SetLogger(l loggers.Contextual) {
+	global.Store(newHolder(l))
+}
 
-func init() {
-	Logger = stdlib.NewDefaultLogger()
+// Replace replaces the global logger and returns a function restoring the previous one.
+// It is typically used in tests:
+//
+//	defer log.Replace(myLogger)()
+func Replace(l loggers.Contextual) (restore func()) {
+	prev := global.Swap(newHolder(l))
+	return func() {
+		global.Store(prev)
+	}
+}
+
+func newHolder(l loggers.Contextual) *holder {
+	if l == nil {
+		l = defaultLogger()
+	}
+	return &holder{logger: l}
 }
 
 // Debug should be used when logging exessive debug info.
 func Debug(v ...any) {
-	Logger.Debug(v...)
+	Default().Debug(v...)
 }
 
 // Debugf works the same as Debug but supports formatting.
 func Debugf(format string, v ...any) {
-	Logger.Debugf(format, v...)
+	Default().Debugf(format, v...)
 }
 
 // Debugln works the same as Debug but supports formatting.
 func Debugln(v ...any) {
-	Logger.Debugln(v...)
+	Default().Debugln(v...)
 }
 
 // Info is a general function to log something.
 func Info(v ...any) {
-	Logger.Info(v...)
+	Default().Info(v...)
 }
 
 // Infof works the same as Info but supports formatting.
 func Infof(format string, v ...any) {
-	Logger.Infof(format, v...)
+	Default().Infof(format, v...)
 }
 
 // Infoln works the same as Info but supports formatting.
 func Infoln(v ...any) {
-	Logger.Infoln(v...)
+	Default().Infoln(v...)
 }
 
 // Warn is useful for alerting about something wrong.
 func Warn(v ...any) {
-	Logger.Warn(v...)
+	Default().Warn(v...)
 }
 
 // Warnf works the same as Warn but supports formatting.
 func Warnf(format string, v ...any) {
-	Logger.Warnf(format, v...)
+	Default().Warnf(format, v...)
 }
 
 // Warnln works the same as Warn but prints each value on a line.
 func Warnln(v ...any) {
-	Logger.Warnln(v...)
+	Default().Warnln(v...)
 }
 
 // Error should be used only if real error occures.
 func Error(v ...any) {
-	Logger.Error(v...)
+	Default().Error(v...)
 }
 
 // Errorf works the same as Error but supports formatting.
 func Errorf(format string, v ...any) {
-	Logger.Errorf(format, v...)
+	Default().Errorf(format, v...)
 }
 
 // Errorln works the same as Error but prints each value on a line.
 func Errorln(v ...any) {
-	Logger.Errorln(v...)
+	Default().Errorln(v...)
 }
 
 // Fatal should be only used when it's not possible to continue program execution.
 func Fatal(v ...any) {
-	Logger.Fatal(v...)
+	Default().Fatal(v...)
 }
 
 // Fatalf works the same as Fatal but supports formatting.
 func Fatalf(format string, v ...any) {
-	Logger.Fatalf(format, v...)
+	Default().Fatalf(format, v...)
 }
 
 // Fatalln works the same as Fatal but prints each value on a line.
 func Fatalln(v ...any) {
-	Logger.Fatalln(v...)
+	Default().Fatalln(v...)
 }
 
 // Panic should be used only if real panic is desired.
 func Panic(v ...any) {
-	Logger.Panic(v...)
+	Default().Panic(v...)
 }
 
 // Panicf works the same as Panic but supports formatting.
 func Panicf(format string, v ...any) {
-	Logger.Panicf(format, v...)
+	Default().Panicf(format, v...)
 }
 
 // Panicln works the same as Panic but prints each value on a line.
 func Panicln(v ...any) {
-	Logger.Panicln(v...)
+	Default().Panicln(v...)
 }
 
 // Print should be used for information messages.
 func Print(v ...any) {
-	Logger.Print(v...)
+	Default().Print(v...)
 }
 
 // Printf works the same as Print but supports formatting.
 func Printf(format string, v ...any) {
-	Logger.Printf(format, v...)
+	Default().Printf(format, v...)
 }
 
 // Println works the same as Print but prints each value on a line.
 func Println(v ...any) {
-	Logger.Println(v...)
+	Default().Println(v...)
 }
 
 // WithField adds the key value as parameter to log.
 func WithField(key string, value any) loggers.Contextual {
-	return Logger.WithField(key, value)
+	return Default().WithField(key, value)
 }
 
 // WithFields adds the fields as a list of key/value parameters to log. Even number expected.
 func WithFields(fields ...any) loggers.Contextual {
-	return Logger.WithFields(fields...)
+	return Default().WithFields(fields...)
+}
+
+// Named returns a child of the global logger called name, see named.Named.
+func Named(name string) loggers.Contextual {
+	return named.Named(Default(), name)
 }
 
+// GetUnderlying returns the underlying logger of the global logger asserted to T.
+// It panics if the underlying logger is not a T, see Underlying for a safe variant.
 func GetUnderlying[T any]() T {
-	return Logger.GetUnderlying().(T)
+	return Default().GetUnderlying().(T)
+}
+
+// Underlying returns the underlying logger of the global logger and whether it is a T.
+func Underlying[T any]() (T, bool) {
+	u, ok := Default().GetUnderlying().(T)
+	return u, ok
 }
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"bytes"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+func newBufferedLog() (loggers.Contextual, *bytes.Buffer) {
+	b := new(bytes.Buffer)
+	return stdlib.NewLogger(stdlog.New(b, "", 0)), b
+}
+
+func TestReplaceRestores(t *testing.T) {
+	orig := Default()
+	l, b := newBufferedLog()
+
+	restore := Replace(l)
+	Info("replaced")
+	restore()
+
+	if Default() != orig {
+		t.Errorf("Replace restore did not bring back the previous logger")
+	}
+	if !strings.Contains(b.String(), "INFO  replaced") {
+		t.Errorf("Log output mismatch %q (actual) does not contain %q", b.String(), "INFO  replaced")
+	}
+}
+
+func TestSetLoggerNil(t *testing.T) {
+	defer Replace(nil)()
+	if Default() == nil {
+		t.Fatalf("SetLogger(nil) must install a default logger")
+	}
+}
+
+func TestConcurrentSetLogger(t *testing.T) {
+	defer Replace(Default())()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			l, _ := newBufferedLog()
+			SetLogger(l)
+		}()
+		go func() {
+			defer wg.Done()
+			WithField("k", "v").Debug("racing")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUnderlying(t *testing.T) {
+	l, _ := newBufferedLog()
+	defer Replace(l)()
+
+	if _, ok := Underlying[*stdlog.Logger](); !ok {
+		t.Errorf("Underlying must return the wrapped *log.Logger")
+	}
+	if _, ok := Underlying[*bytes.Buffer](); ok {
+		t.Errorf("Underlying must report a type mismatch")
+	}
+	if GetUnderlying[*stdlog.Logger]() == nil {
+		t.Errorf("GetUnderlying must return the wrapped *log.Logger")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		backend, format string
+		expected        string
+	}{
+		{"", "", "INFO  visible"},
+		{"slog", "text", "level=INFO msg=visible"},
+		{"json", "", `"msg":"visible"`},
+		{"logrus", "json", `"msg":"visible"`},
+	}
+	for _, test := range tests {
+		t.Run(test.backend+"/"+test.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.log")
+			t.Setenv(EnvBackend, test.backend)
+			t.Setenv(EnvFormat, test.format)
+			t.Setenv(EnvLevel, "info,db=debug")
+			t.Cleanup(func() { named.DefaultRegistry.SetDirectives("debug") })
+			t.Setenv(EnvOutput, path)
+			t.Setenv(EnvCaller, "true")
+
+			l, err := NewFromEnv()
+			if err != nil {
+				t.Fatalf("NewFromEnv failed: %v", err)
+			}
+			l.Debug("hidden")
+			l.Info("visible")
+			named.Named(l, "db").Debug("db debug")
+
+			b, _ := os.ReadFile(path)
+			s := string(b)
+			if strings.Contains(s, "hidden") {
+				t.Errorf("Debug entry must be filtered out, got %q", s)
+			}
+			if !strings.Contains(s, test.expected) {
+				t.Errorf("Log output mismatch %q (actual) does not contain %q", s, test.expected)
+			}
+			if !strings.Contains(s, "db debug") {
+				t.Errorf("Log output %q must contain the db debug entry", s)
+			}
+			if !strings.Contains(s, "log_test.go:") {
+				t.Errorf("Log output %q must contain the caller", s)
+			}
+		})
+	}
+}
+
+func TestNewFromEnvInvalid(t *testing.T) {
+	t.Setenv(EnvBackend, "zap")
+	t.Setenv(EnvLevel, "info,db=loud")
+	directives := named.DefaultRegistry.Directives()
+
+	l, err := NewFromEnv()
+	if err == nil {
+		t.Errorf("NewFromEnv must report invalid values")
+	}
+	if l == nil {
+		t.Fatalf("NewFromEnv must return a usable logger")
+	}
+	if d := named.DefaultRegistry.Directives(); d != directives {
+		t.Errorf("Invalid levels must not change the registry, got %s", d)
+	}
+	if msg := err.Error(); !strings.Contains(msg, EnvBackend) || !strings.Contains(msg, EnvLevel) || strings.Contains(msg, EnvOutput) {
+		t.Errorf("Errors must name the invalid variables only, got %q", msg)
+	}
+
+	t.Setenv(EnvBackend, "")
+	t.Setenv(EnvLevel, "")
+	t.Setenv(EnvOutput, t.TempDir()) // a directory cannot be opened as a file
+	if _, err := NewFromEnv(); err == nil || !strings.HasPrefix(err.Error(), EnvOutput+": ") {
+		t.Errorf("Output errors must name %s, got %v", EnvOutput, err)
+	}
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// SignalMode selects how NotifyLevelSignals reacts to signals.
+type SignalMode int
+
+const (
+	// StepLevel makes SIGUSR1 lower the global level by one step, i.e. more verbose,
+	// and SIGUSR2 raise it by one step, between debug and error.
+	StepLevel SignalMode = iota
+	// ToggleDebug makes SIGUSR1 switch the global level to debug, and back to the
+	// previous level on the next SIGUSR1. SIGUSR2 is ignored.
+	ToggleDebug
+)
+
+// levelSetter returns the global logger as a LevelSetter. If the global logger
+// cannot change its level, it is replaced by a mappers.LevelFilter over it.
+func levelSetter() mappers.LevelSetter {
+	for {
+		Default()
+		h := global.Load()
+		if s, ok := h.logger.(mappers.LevelSetter); ok {
+			return s
+		}
+		f := mappers.NewLevelFilter(h.logger, nil)
+		if global.CompareAndSwap(h, &holder{logger: f}) {
+			return f
+		}
+	}
+}
+
+// setGlobalLevel changes the level of the global logger and logs the change, at Info or at
+// Warn when the new level is above Info. Above Warn, the change is logged before the new
+// level applies, so that it is not filtered out.
+func setGlobalLevel(l mappers.Level, reason string) {
+	lev := mappers.LevelInfo
+	if l > mappers.LevelInfo {
+		lev = mappers.LevelWarn
+	}
+	if l > lev {
+		mappers.Dispatchf(Default(), lev, "Log level changed to %s by %s", levelName(l), reason)
+		levelSetter().SetLevel(l)
+		return
+	}
+	levelSetter().SetLevel(l)
+	mappers.Dispatchf(Default(), lev, "Log level changed to %s by %s", levelName(l), reason)
+}
+
+func levelName(l mappers.Level) string {
+	text, _ := l.MarshalText()
+	return string(text)
+}
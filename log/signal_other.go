@@ -0,0 +1,9 @@
+//go:build !unix
+
+package log
+
+// NotifyLevelSignals changes the level of the global logger when the process receives
+// SIGUSR1 or SIGUSR2. These signals do not exist on this platform, so it does nothing.
+func NotifyLevelSignals(mode SignalMode) (stop func()) {
+	return func() {}
+}
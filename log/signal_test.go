@@ -0,0 +1,76 @@
+//go:build unix
+
+package log
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+func waitLevel(t *testing.T, expected mappers.Level) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for levelSetter().Level() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("Level mismatch %v (actual) != %v (expected)", levelSetter().Level(), expected)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNotifyLevelSignalsStep(t *testing.T) {
+	l, b := newBufferedLog()
+	defer Replace(l)()
+	levelSetter().SetLevel(mappers.LevelInfo)
+
+	stop := NotifyLevelSignals(StepLevel)
+	defer stop()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(t, mappers.LevelDebug)
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitLevel(t, mappers.LevelInfo)
+	stop()
+
+	if !strings.Contains(b.String(), "INFO  Log level changed to debug by user defined signal 1") {
+		t.Errorf("Log output %q must report the level change", b.String())
+	}
+}
+
+func TestNotifyLevelSignalsStepToError(t *testing.T) {
+	l, b := newBufferedLog()
+	defer Replace(l)()
+	levelSetter().SetLevel(mappers.LevelWarn)
+
+	stop := NotifyLevelSignals(StepLevel)
+	defer stop()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitLevel(t, mappers.LevelError)
+	stop()
+
+	if !strings.Contains(b.String(), "WARN  Log level changed to error by user defined signal 2") {
+		t.Errorf("Log output %q must report the level change as a warning", b.String())
+	}
+	if strings.Contains(b.String(), "ERROR") {
+		t.Errorf("Log output %q must not hold errors", b.String())
+	}
+}
+
+func TestNotifyLevelSignalsToggle(t *testing.T) {
+	l, _ := newBufferedLog()
+	defer Replace(l)()
+	levelSetter().SetLevel(mappers.LevelWarn)
+
+	stop := NotifyLevelSignals(ToggleDebug)
+	defer stop()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(t, mappers.LevelDebug)
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(t, mappers.LevelWarn)
+}
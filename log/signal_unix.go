@@ -0,0 +1,62 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// NotifyLevelSignals changes the level of the global logger when the process receives
+// SIGUSR1 or SIGUSR2, according to mode. It works with any global logger, replacing it
+// by a mappers.LevelFilter over it when it cannot change its level itself.
+// The returned function stops listening for the signals and waits for pending changes.
+// On platforms without these signals, it does nothing.
+func NotifyLevelSignals(mode SignalMode) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var previous mappers.Level
+		toggled := false
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signals:
+				current := levelSetter().Level()
+				switch {
+				case mode == ToggleDebug && sig == syscall.SIGUSR1:
+					if toggled {
+						setGlobalLevel(previous, sig.String())
+					} else {
+						previous = current
+						setGlobalLevel(mappers.LevelDebug, sig.String())
+					}
+					toggled = !toggled
+				case mode == StepLevel && sig == syscall.SIGUSR1 && current > mappers.LevelDebug:
+					setGlobalLevel(min(current, mappers.LevelError+1)-1, sig.String())
+				case mode == StepLevel && sig == syscall.SIGUSR2 && current < mappers.LevelError:
+					setGlobalLevel(current+1, sig.String())
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"io"
+	stdlog "log"
+	"strings"
+)
+
+// These flags are the same as the ones of the standard library log package.
+const (
+	Ldate         = stdlog.Ldate
+	Ltime         = stdlog.Ltime
+	Lmicroseconds = stdlog.Lmicroseconds
+	Llongfile     = stdlog.Llongfile
+	Lshortfile    = stdlog.Lshortfile
+	LUTC          = stdlog.LUTC
+	Lmsgprefix    = stdlog.Lmsgprefix
+	LstdFlags     = stdlog.LstdFlags
+)
+
+// Logger is the standard library logger, so that code declaring *log.Logger keeps compiling.
+type Logger = stdlog.Logger
+
+// New creates a standard library logger, see the standard library log.New.
+func New(out io.Writer, prefix string, flag int) *Logger {
+	return stdlog.New(out, prefix, flag)
+}
+
+// std returns the standard library logger backing the global logger, if any.
+func std() (*stdlog.Logger, bool) {
+	return Underlying[*stdlog.Logger]()
+}
+
+// SetOutput sets the output destination of the global logger.
+// It has no effect if the global logger is not backed by a standard library logger:
+// the output of other backends is set when they are built, and replacing the global
+// logger would silently drop its backend, level and fields.
+func SetOutput(w io.Writer) {
+	if l, ok := std(); ok {
+		l.SetOutput(w)
+	}
+}
+
+// Flags returns the output flags of the global logger.
+// It returns 0 if the global logger is not backed by a standard library logger.
+func Flags() int {
+	if l, ok := std(); ok {
+		return l.Flags()
+	}
+	return 0
+}
+
+// SetFlags sets the output flags of the global logger.
+// It has no effect if the global logger is not backed by a standard library logger.
+func SetFlags(flag int) {
+	if l, ok := std(); ok {
+		l.SetFlags(flag)
+	}
+}
+
+// Prefix returns the output prefix of the global logger.
+// It returns an empty string if the global logger is not backed by a standard library logger.
+func Prefix() string {
+	if l, ok := std(); ok {
+		return l.Prefix()
+	}
+	return ""
+}
+
+// SetPrefix sets the output prefix of the global logger.
+// It has no effect if the global logger is not backed by a standard library logger.
+func SetPrefix(prefix string) {
+	if l, ok := std(); ok {
+		l.SetPrefix(prefix)
+	}
+}
+
+// Writer returns the output destination of the global logger.
+// If the global logger is not backed by a standard library logger, the returned
+// writer logs every write as an Info entry of the global logger.
+func Writer() io.Writer {
+	if l, ok := std(); ok {
+		return l.Writer()
+	}
+	return logWriter{}
+}
+
+// Output writes the output for a logging event, see the standard library log.Output.
+// If the global logger is not backed by a standard library logger, s is logged
+// as an Info entry and calldepth is ignored.
+func Output(calldepth int, s string) error {
+	if l, ok := std(); ok {
+		return l.Output(calldepth+1, s)
+	}
+	Default().Print(strings.TrimSuffix(s, "\n"))
+	return nil
+}
+
+// logWriter forwards each write to the current global logger.
+type logWriter struct{}
+
+func (logWriter) Write(p []byte) (int, error) {
+	Default().Print(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
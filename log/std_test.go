@@ -0,0 +1,182 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	stdslog "log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/marcaudefroy/loggers/mappers/slog"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+// These tests exercise the usage patterns of the standard library log package
+// against this package, as done by code switching its import.
+
+const (
+	rDate         = `[0-9][0-9][0-9][0-9]/[0-9][0-9]/[0-9][0-9]`
+	rTime         = `[0-9][0-9]:[0-9][0-9]:[0-9][0-9]`
+	rMicroseconds = `\.[0-9][0-9][0-9][0-9][0-9][0-9]`
+	rShortfile    = `std_test.go:[0-9]+`
+)
+
+func withStdlibGlobal(t *testing.T) {
+	t.Helper()
+	t.Cleanup(Replace(stdlib.NewDefaultLogger()))
+}
+
+func TestStdFlagAndPrefixSetting(t *testing.T) {
+	withStdlibGlobal(t)
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetPrefix("Test:")
+	SetFlags(Ldate | Ltime | Lmicroseconds)
+
+	if f := Flags(); f != Ldate|Ltime|Lmicroseconds {
+		t.Errorf("Flags mismatch %d (actual) != %d (expected)", f, Ldate|Ltime|Lmicroseconds)
+	}
+	if p := Prefix(); p != "Test:" {
+		t.Errorf("Prefix mismatch %q (actual) != %q (expected)", p, "Test:")
+	}
+	if Writer() != &b {
+		t.Errorf("Writer must return the writer set by SetOutput")
+	}
+
+	Print("hello")
+	pattern := "^Test:" + rDate + " " + rTime + rMicroseconds + " INFO  hello\n$"
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), pattern)
+	}
+}
+
+func TestStdMsgPrefix(t *testing.T) {
+	withStdlibGlobal(t)
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetPrefix("Test:")
+	SetFlags(Lmsgprefix)
+
+	Printf("hello %d", 23)
+	if expected := "Test:INFO  hello 23\n"; b.String() != expected {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), expected)
+	}
+}
+
+func TestStdOutput(t *testing.T) {
+	withStdlibGlobal(t)
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetFlags(Lshortfile)
+
+	if err := Output(1, "hello"); err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	pattern := "^" + rShortfile + ": hello\n$"
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), pattern)
+	}
+}
+
+func TestStdEmptyPrintCreatesLine(t *testing.T) {
+	withStdlibGlobal(t)
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetFlags(0)
+
+	Print()
+	Println("non-empty")
+	if n := strings.Count(b.String(), "\n"); n != 2 {
+		t.Errorf("Expected 2 lines, got %d in %q", n, b.String())
+	}
+}
+
+func TestStdNew(t *testing.T) {
+	var b bytes.Buffer
+	var l *Logger = New(&b, "new: ", 0)
+	l.Println("hello")
+	if expected := "new: hello\n"; b.String() != expected {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), expected)
+	}
+}
+
+func TestStdPanicRecover(t *testing.T) {
+	withStdlibGlobal(t)
+	SetOutput(new(bytes.Buffer))
+
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok || err.Error() != "oops 1" {
+			t.Errorf("Panic value mismatch %v (actual) != %q (expected)", r, "oops 1")
+		}
+	}()
+	Panicf("oops %d", 1)
+}
+
+func TestStdNonStdlibGlobal(t *testing.T) {
+	var b bytes.Buffer
+	defer Replace(slog.NewLogger(stdslog.New(stdslog.NewJSONHandler(&b, nil))))()
+
+	SetFlags(Lshortfile)
+	SetPrefix("ignored")
+	if Flags() != 0 || Prefix() != "" {
+		t.Errorf("Flags and prefix must be empty for a non stdlib global logger")
+	}
+
+	Writer().Write([]byte("through writer\n"))
+	if err := Output(1, "through output"); err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+
+	dec := json.NewDecoder(&b)
+	for _, expected := range []string{"through writer", "through output"} {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("Failed to decode entry: %v", err)
+		}
+		if entry["msg"] != expected {
+			t.Errorf("Message mismatch %v (actual) != %q (expected)", entry["msg"], expected)
+		}
+	}
+
+	var out bytes.Buffer
+	SetOutput(&out)
+	Print("kept")
+	if out.Len() != 0 {
+		t.Errorf("SetOutput must not replace a non stdlib global logger, got %q", out.String())
+	}
+	var entry map[string]any
+	if err := dec.Decode(&entry); err != nil || entry["msg"] != "kept" {
+		t.Errorf("Message mismatch %v (actual) != %q (expected), %v", entry["msg"], "kept", err)
+	}
+}
+
+func TestStdShortfile(t *testing.T) {
+	withStdlibGlobal(t)
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetFlags(Lshortfile)
+
+	Print("hello")
+	Printf("hello %d", 23)
+	Println("hello")
+	pattern := "^" + rShortfile + ": INFO  hello\n" + rShortfile + ": INFO  hello 23\n" + rShortfile + ": INFO   hello\n$"
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), pattern)
+	}
+}
+
+func TestStdLongfileWithFields(t *testing.T) {
+	withStdlibGlobal(t)
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetFlags(Llongfile)
+
+	WithField("k", "v").Info("hello")
+	pattern := "^/.*/log/" + rShortfile + ": INFO  hello \\[k=v\\]\n$"
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), pattern)
+	}
+}
@@ -0,0 +1,105 @@
+package mappers
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+// modulePath prefixes the functions of this module, skipped when looking for the caller.
+const modulePath = "github.com/marcaudefroy/loggers"
+
+// CallerKey is the field key used by NewCallerLogger.
+const CallerKey = "caller"
+
+// Caller returns the frame of the first function outside of this module on the calling stack,
+// that is the code which called a logging method. Test files of this module are not skipped.
+func Caller() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !isModuleFrame(f) {
+			return f, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// CallerDepth returns the depth of the frame returned by Caller relative to the function calling
+// CallerDepth, as given to runtime.Caller. It returns 1, the caller of that function, if no
+// frame is outside of this module.
+func CallerDepth() int {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for depth := 0; ; depth++ {
+		f, more := frames.Next()
+		if !isModuleFrame(f) {
+			return depth
+		}
+		if !more {
+			return 1
+		}
+	}
+}
+
+func isModuleFrame(f runtime.Frame) bool {
+	if strings.HasSuffix(f.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(f.Function, modulePath+".") || strings.HasPrefix(f.Function, modulePath+"/")
+}
+
+// ShortCaller formats a frame as dir/file.go:line.
+func ShortCaller(f runtime.Frame) string {
+	dir, file := filepath.Split(f.File)
+	return filepath.Join(filepath.Base(dir), file) + ":" + strconv.Itoa(f.Line)
+}
+
+// NewCallerLogger returns a Contextual logger adding the location of the logging call
+// to every entry as the CallerKey field.
+func NewCallerLogger(l loggers.Contextual) loggers.Contextual {
+	return NewContextualMap(&callerLogger{logger: l})
+}
+
+type callerLogger struct {
+	logger loggers.Contextual
+}
+
+func (c *callerLogger) GetUnderlying() any {
+	return c.logger.GetUnderlying()
+}
+
+func (c *callerLogger) withCaller() loggers.Contextual {
+	if f, ok := Caller(); ok {
+		return c.logger.WithField(CallerKey, ShortCaller(f))
+	}
+	return c.logger
+}
+
+func (c *callerLogger) LevelPrint(lev Level, v ...any) {
+	Dispatch(c.withCaller(), lev, v...)
+}
+
+func (c *callerLogger) LevelPrintf(lev Level, format string, v ...any) {
+	Dispatchf(c.withCaller(), lev, format, v...)
+}
+
+func (c *callerLogger) LevelPrintln(lev Level, v ...any) {
+	Dispatchln(c.withCaller(), lev, v...)
+}
+
+func (c *callerLogger) WithField(key string, value any) loggers.Contextual {
+	return NewCallerLogger(c.logger.WithField(key, value))
+}
+
+func (c *callerLogger) WithFields(fields ...any) loggers.Contextual {
+	return NewCallerLogger(c.logger.WithFields(fields...))
+}
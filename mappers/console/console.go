@@ -0,0 +1,298 @@
+// Package console maps a Contextual logger to a human friendly, colorized output for development.
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// ColorMode tells when the output is colorized.
+type ColorMode int
+
+const (
+	// ColorAuto colorizes the output when it is a terminal and NO_COLOR is not set.
+	ColorAuto ColorMode = iota
+	// ColorAlways always colorizes the output.
+	ColorAlways
+	// ColorNever never colorizes the output.
+	ColorNever
+)
+
+// DefaultTimeLayout is the default layout of the entry times.
+const DefaultTimeLayout = "15:04:05.000"
+
+// Options configures a console logger. The zero value is usable.
+type Options struct {
+	// Color tells when the output is colorized, ColorAuto by default.
+	Color ColorMode
+	// TimeLayout is the layout of the entry times, DefaultTimeLayout by default.
+	TimeLayout string
+	// Compact renders each entry on a single line, multi-line messages and values being escaped,
+	// for CI logs and other outputs read as a stream of lines.
+	Compact bool
+}
+
+// ANSI escape sequences.
+const (
+	reset   = "\x1b[0m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	yellow  = "\x1b[33m"
+	magenta = "\x1b[35m"
+	cyan    = "\x1b[36m"
+)
+
+var levelColors = [...]string{
+	mappers.LevelDebug: magenta,
+	mappers.LevelInfo:  green,
+	mappers.LevelWarn:  yellow,
+	mappers.LevelError: red,
+	mappers.LevelFatal: bold + red,
+	mappers.LevelPanic: bold + red,
+}
+
+// indent is the indentation of the lines of multi-line values.
+const indent = "    "
+
+// output is the writer shared by a logger and its children.
+type output struct {
+	mu      sync.Mutex
+	w       io.Writer
+	color   bool
+	layout  string
+	compact bool
+}
+
+// consoleLogger maps a writer to a Contextual logger interface.
+type consoleLogger struct {
+	out    *output
+	fields []any
+}
+
+// NewLogger returns a Contextual logger writing human friendly entries to w: the time, the level
+// aligned and colored, the message and the fields with highlighted keys. Errors with a stack trace,
+// multi-line strings, structs and maps are rendered indented on the following lines.
+func NewLogger(w io.Writer, opts Options) loggers.Contextual {
+	out := output{w: w, layout: opts.TimeLayout, compact: opts.Compact}
+	if out.layout == "" {
+		out.layout = DefaultTimeLayout
+	}
+	out.color = UseColor(w, opts.Color)
+	return mappers.NewContextualMap(&consoleLogger{out: &out})
+}
+
+// UseColor reports whether the output to w is colorized in the given mode, so that other
+// loggers writing to w can follow the same rules as the console logger.
+func UseColor(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return IsTerminal(w) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	}
+	return false
+}
+
+// NewDefaultLogger returns a Contextual console logger writing to stderr.
+func NewDefaultLogger() loggers.Contextual {
+	return NewLogger(os.Stderr, Options{})
+}
+
+// IsTerminal reports whether w is a character device, typically a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (l *consoleLogger) GetUnderlying() any {
+	return l.out.w
+}
+
+// LevelPrint is a Mapper method
+func (l *consoleLogger) LevelPrint(lev mappers.Level, i ...any) {
+	l.print(lev, fmt.Sprint(i...))
+}
+
+// LevelPrintf is a Mapper method
+func (l *consoleLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	l.print(lev, fmt.Sprintf(format, i...))
+}
+
+// LevelPrintln is a Mapper method
+func (l *consoleLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	l.print(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"))
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *consoleLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *consoleLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := consoleLogger{out: l.out, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...)}
+	return mappers.NewContextualMap(&nl)
+}
+
+func (l *consoleLogger) print(lev mappers.Level, msg string) {
+	o := l.out
+	var b, blocks strings.Builder
+
+	o.paint(&b, dim, time.Now().Format(o.layout))
+	b.WriteByte(' ')
+	name, _ := lev.MarshalText()
+	if int(lev) < len(levelColors) {
+		o.paint(&b, levelColors[lev], fmt.Sprintf("%-5s", strings.ToUpper(string(name))))
+	} else {
+		fmt.Fprintf(&b, "%-5d", lev)
+	}
+	b.WriteByte(' ')
+
+	first, rest, multiline := strings.Cut(msg, "\n")
+	if o.compact && multiline {
+		b.WriteString(escape(msg))
+	} else {
+		b.WriteString(first)
+		if multiline {
+			writeIndented(&blocks, rest)
+		}
+	}
+
+	for i := 0; i+1 < len(l.fields); i = i + 2 {
+		key := fmt.Sprint(l.fields[i])
+		value, block := o.render(l.fields[i+1])
+		if block {
+			blocks.WriteString(indent[:2])
+			o.paint(&blocks, cyan, key)
+			blocks.WriteString(":\n")
+			writeIndented(&blocks, value)
+			continue
+		}
+		b.WriteByte(' ')
+		o.paint(&b, cyan, key)
+		b.WriteByte('=')
+		b.WriteString(value)
+	}
+	b.WriteByte('\n')
+	b.WriteString(blocks.String())
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	io.WriteString(o.w, b.String())
+}
+
+// Paint returns s colored with the color of the console logger for lev.
+func Paint(lev mappers.Level, s string) string {
+	if int(lev) >= len(levelColors) {
+		return s
+	}
+	return levelColors[lev] + s + reset
+}
+
+// paint writes s to b, colored if the output is colorized.
+func (o *output) paint(b *strings.Builder, color, s string) {
+	if !o.color {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(color)
+	b.WriteString(s)
+	b.WriteString(reset)
+}
+
+// render returns the rendering of a field value, and whether it is a block rendered on the following lines.
+func (o *output) render(v any) (string, bool) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return "<nil>", false
+	case error:
+		// %+v renders the stack trace of the errors which have one. fmt is used rather than
+		// calling Error so that nil pointers are rendered as <nil> instead of panicking.
+		if s = fmt.Sprintf("%+v", v); !strings.Contains(s, "\n") {
+			return quote(fmt.Sprint(v)), false
+		}
+	case string:
+		s = v
+	case fmt.Stringer:
+		s = fmt.Sprint(v)
+	case time.Time, time.Duration:
+		return quote(fmt.Sprint(v)), false
+	default:
+		if !isComposite(v) {
+			return quote(fmt.Sprint(v)), false
+		}
+		if o.compact {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return quote(fmt.Sprintf("%+v", v)), false
+			}
+			return string(b), false
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return quote(fmt.Sprintf("%+v", v)), false
+		}
+		s = string(b)
+	}
+	if !strings.Contains(s, "\n") {
+		return quote(s), false
+	}
+	if o.compact {
+		return quote(s), false
+	}
+	return s, true
+}
+
+// isComposite reports whether v is a struct, map or slice, or a pointer to one, except byte slices.
+func isComposite(v any) bool {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// quote quotes s if it is empty or holds spaces, quotes or non printable characters.
+func quote(s string) string {
+	if s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '"' || r == '=' || !strconv.IsPrint(r)
+	}) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// escape escapes the line breaks of s, so that it is rendered on a single line.
+var escape = strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace
+
+// writeIndented writes the lines of s indented.
+func writeIndented(b *strings.Builder, s string) {
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		b.WriteString(indent)
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+}
@@ -0,0 +1,140 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+func TestConsoleInterface(t *testing.T) {
+	var _ loggers.Contextual = NewDefaultLogger()
+}
+
+// stackError is an error rendering a stack trace with %+v, like github.com/pkg/errors.
+type stackError struct{}
+
+func (stackError) Error() string { return "failed" }
+func (stackError) Format(s fmt.State, verb rune) {
+	if s.Flag('+') {
+		fmt.Fprint(s, "failed\nmain.run\n\tmain.go:12")
+		return
+	}
+	fmt.Fprint(s, "failed")
+}
+
+type user struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+func TestConsoleOutput(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf, Options{Color: ColorNever})
+
+	l.WithFields("user", "bob", "msg", "two words", "err", errors.New("boom"), "n", 1).Info("started")
+	l.Warnf("multi\nline %d", 2)
+	l.WithFields("err", stackError{}, "u", user{"bob", true}).Error("failed")
+
+	expected := `^\d\d:\d\d:\d\d\.\d{3} INFO  started user=bob msg="two words" err=boom n=1
+\d\d:\d\d:\d\d\.\d{3} WARN  multi
+    line 2
+\d\d:\d\d:\d\d\.\d{3} ERROR failed
+  err:
+    failed
+    main\.run
+    	main\.go:12
+  u:
+    \{
+      "name": "bob",
+      "admin": true
+    \}
+$`
+	if !regexp.MustCompile(expected).MatchString(buf.String()) {
+		t.Errorf("Output mismatch %q (actual) != %q (expected)", buf.String(), expected)
+	}
+}
+
+func TestConsoleCompact(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf, Options{Color: ColorNever, Compact: true, TimeLayout: "T"})
+
+	l.WithFields("err", stackError{}, "u", user{"bob", true}, "ids", []int{1, 2}).Debug("multi\nline")
+	expected := `T DEBUG multi\nline err="failed\nmain.run\n\tmain.go:12" u={"name":"bob","admin":true} ids=[1,2]` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Output mismatch %q (actual) != %q (expected)", buf.String(), expected)
+	}
+}
+
+type host struct{ name string }
+
+func (h *host) String() string { return h.name }
+
+func TestConsoleNilPointers(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf, Options{Color: ColorNever, TimeLayout: "T"})
+
+	var err *url.Error
+	var h *host
+	l.WithFields("err", err, "host", h).Info("nil")
+	if expected := "T INFO  nil err=<nil> host=<nil>\n"; buf.String() != expected {
+		t.Errorf("Output mismatch %q (actual) != %q (expected)", buf.String(), expected)
+	}
+}
+
+func TestConsoleColor(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogger(&buf, Options{Color: ColorAlways, TimeLayout: "T"}).WithField("k", "v").Warn("colored")
+	expected := "\x1b[2mT\x1b[0m \x1b[33mWARN \x1b[0m colored \x1b[36mk\x1b[0m=v\n"
+	if buf.String() != expected {
+		t.Errorf("Output mismatch %q (actual) != %q (expected)", buf.String(), expected)
+	}
+
+	buf.Reset()
+	NewLogger(&buf, Options{}).Info("auto")
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("Unexpected colors on a buffer %q", buf.String())
+	}
+}
+
+func TestConsoleAutoColor(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skipf("No %s: %v", os.DevNull, err)
+	}
+	defer f.Close()
+	if !IsTerminal(f) {
+		t.Skipf("%s is not a character device", os.DevNull)
+	}
+
+	t.Setenv("TERM", "xterm")
+	t.Setenv("NO_COLOR", "")
+	if !UseColor(f, ColorAuto) {
+		t.Errorf("Expected colors on a character device")
+	}
+	if UseColor(f, ColorNever) {
+		t.Errorf("Unexpected colors with ColorNever")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if UseColor(f, ColorAuto) {
+		t.Errorf("Unexpected colors with NO_COLOR set")
+	}
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "dumb")
+	if UseColor(f, ColorAuto) {
+		t.Errorf("Unexpected colors on a dumb terminal")
+	}
+}
+
+func TestPaint(t *testing.T) {
+	if s := Paint(mappers.LevelWarn, "WARN"); s != "\x1b[33mWARN\x1b[0m" {
+		t.Errorf("Paint mismatch %q (actual) != %q (expected)", s, "\x1b[33mWARN\x1b[0m")
+	}
+}
@@ -0,0 +1,58 @@
+package mappers
+
+import "github.com/marcaudefroy/loggers"
+
+// Dispatch calls the Print style method of l matching lev.
+// It allows decorators to forward a mapped call to any Advanced logger.
+func Dispatch(l loggers.Advanced, lev Level, v ...any) {
+	switch lev {
+	case LevelDebug:
+		l.Debug(v...)
+	case LevelInfo:
+		l.Info(v...)
+	case LevelWarn:
+		l.Warn(v...)
+	case LevelError:
+		l.Error(v...)
+	case LevelFatal:
+		l.Fatal(v...)
+	case LevelPanic:
+		l.Panic(v...)
+	}
+}
+
+// Dispatchf calls the Printf style method of l matching lev.
+func Dispatchf(l loggers.Advanced, lev Level, format string, v ...any) {
+	switch lev {
+	case LevelDebug:
+		l.Debugf(format, v...)
+	case LevelInfo:
+		l.Infof(format, v...)
+	case LevelWarn:
+		l.Warnf(format, v...)
+	case LevelError:
+		l.Errorf(format, v...)
+	case LevelFatal:
+		l.Fatalf(format, v...)
+	case LevelPanic:
+		l.Panicf(format, v...)
+	}
+}
+
+// Dispatchln calls the Println style method of l matching lev.
+func Dispatchln(l loggers.Advanced, lev Level, v ...any) {
+	switch lev {
+	case LevelDebug:
+		l.Debugln(v...)
+	case LevelInfo:
+		l.Infoln(v...)
+	case LevelWarn:
+		l.Warnln(v...)
+	case LevelError:
+		l.Errorln(v...)
+	case LevelFatal:
+		l.Fatalln(v...)
+	case LevelPanic:
+		l.Panicln(v...)
+	}
+}
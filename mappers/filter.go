@@ -0,0 +1,65 @@
+package mappers
+
+import "github.com/marcaudefroy/loggers"
+
+// LevelFilter is a Contextual logger dropping the entries below a minimum level.
+// Loggers derived with WithField and WithFields share the same minimum level.
+type LevelFilter struct {
+	*ContextualMap
+	min *LevelVar
+}
+
+// NewLevelFilter returns a LevelFilter over l using min as minimum level.
+// A nil min is replaced by a new LevelVar, letting every entry through until SetLevel is called.
+func NewLevelFilter(l loggers.Contextual, min *LevelVar) *LevelFilter {
+	if min == nil {
+		min = new(LevelVar)
+	}
+	f := levelFilter{logger: l, min: min}
+	return &LevelFilter{ContextualMap: NewContextualMap(&f), min: min}
+}
+
+// Level returns the current minimum level.
+func (f *LevelFilter) Level() Level {
+	return f.min.Level()
+}
+
+// SetLevel changes the minimum level of the filter and of all loggers derived from it.
+func (f *LevelFilter) SetLevel(l Level) {
+	f.min.Set(l)
+}
+
+type levelFilter struct {
+	logger loggers.Contextual
+	min    *LevelVar
+}
+
+func (f *levelFilter) GetUnderlying() any {
+	return f.logger.GetUnderlying()
+}
+
+func (f *levelFilter) LevelPrint(lev Level, v ...any) {
+	if lev >= f.min.Level() {
+		Dispatch(f.logger, lev, v...)
+	}
+}
+
+func (f *levelFilter) LevelPrintf(lev Level, format string, v ...any) {
+	if lev >= f.min.Level() {
+		Dispatchf(f.logger, lev, format, v...)
+	}
+}
+
+func (f *levelFilter) LevelPrintln(lev Level, v ...any) {
+	if lev >= f.min.Level() {
+		Dispatchln(f.logger, lev, v...)
+	}
+}
+
+func (f *levelFilter) WithField(key string, value any) loggers.Contextual {
+	return NewLevelFilter(f.logger.WithField(key, value), f.min)
+}
+
+func (f *levelFilter) WithFields(fields ...any) loggers.Contextual {
+	return NewLevelFilter(f.logger.WithFields(fields...), f.min)
+}
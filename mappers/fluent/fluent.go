@@ -0,0 +1,384 @@
+// Package fluent maps a Contextual logger to a Fluentd or Fluent Bit forwarder,
+// using the Forward protocol over TCP or a unix socket.
+package fluent
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+const (
+	// DefaultTag is the tag of the entries of unnamed loggers.
+	DefaultTag = "app"
+	// DefaultFlushInterval is the default interval between sends of the buffered entries.
+	DefaultFlushInterval = time.Second
+	// DefaultBatchSize is the default number of buffered entries triggering a send.
+	DefaultBatchSize = 100
+	// DefaultBufferLimit is the default maximum number of entries buffered while disconnected.
+	DefaultBufferLimit = 10000
+	// DefaultTimeout is the default timeout of dialing, writing and waiting for an acknowledgment.
+	DefaultTimeout = 5 * time.Second
+)
+
+// Options configures a Forwarder. The zero value is usable.
+type Options struct {
+	// Tag is the tag of the entries, DefaultTag by default. The name of a named logger
+	// is appended to it, so entries of the "db" logger are tagged "app.db".
+	Tag string
+	// FlushInterval is the interval between sends of the buffered entries.
+	FlushInterval time.Duration
+	// BatchSize is the number of buffered entries triggering a send before the interval.
+	BatchSize int
+	// BufferLimit is the maximum number of entries kept while the forwarder is unreachable,
+	// the oldest entries are dropped beyond it.
+	BufferLimit int
+	// RequireAck asks the forwarder to acknowledge each batch, which is kept and sent again
+	// until it is acknowledged.
+	RequireAck bool
+	// Timeout bounds dialing, writing and waiting for an acknowledgment.
+	Timeout time.Duration
+}
+
+// Forwarder buffers entries and sends them in PackedForward batches, one per tag.
+// Entries are kept while the forwarder is unreachable and sent once it is back.
+// It is safe for concurrent use.
+type Forwarder struct {
+	network string
+	address string
+	opts    Options
+
+	mu      sync.Mutex
+	pending []event
+	dropped int
+	failing bool
+
+	sendMu sync.Mutex
+	conn   *conn
+
+	flush     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// event is an entry encoded as a MessagePack [time, record] array.
+type event struct {
+	tag  string
+	data []byte
+}
+
+// conn is a connection to the forwarder. Without acknowledgments, the peer closing it
+// is detected by a reading goroutine, otherwise the acknowledgments are read from it.
+type conn struct {
+	net.Conn
+	r      *bufio.Reader
+	closed chan struct{}
+}
+
+// New returns a Forwarder sending entries to the forwarder listening on the given
+// network, "tcp" or "unix", and address. It connects on the first send.
+func New(network, address string, opts Options) *Forwarder {
+	if opts.Tag == "" {
+		opts.Tag = DefaultTag
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.BufferLimit <= 0 {
+		opts.BufferLimit = DefaultBufferLimit
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	f := Forwarder{
+		network: network,
+		address: address,
+		opts:    opts,
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	f.wg.Add(1)
+	go f.run()
+	return &f
+}
+
+// Post buffers an entry with the given tag, time and record.
+func (f *Forwarder) Post(tag string, t time.Time, record map[string]any) {
+	data := appendValue(appendEventTime(appendArrayHeader(nil, 2), t), record)
+
+	f.mu.Lock()
+	f.pending = append(f.pending, event{tag: tag, data: data})
+	f.trim()
+	full := len(f.pending) >= f.opts.BatchSize
+	f.mu.Unlock()
+
+	if full {
+		select {
+		case f.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (f *Forwarder) Dropped() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// trim drops the oldest entries beyond the buffer limit. f.mu must be held.
+func (f *Forwarder) trim() {
+	if n := len(f.pending) - f.opts.BufferLimit; n > 0 {
+		f.pending = append(f.pending[:0], f.pending[n:]...)
+		f.dropped += n
+	}
+}
+
+// Flush sends the buffered entries. Entries which could not be sent are kept for the next flush.
+func (f *Forwarder) Flush() error {
+	f.sendMu.Lock()
+	defer f.sendMu.Unlock()
+
+	f.mu.Lock()
+	events := f.pending
+	f.pending = nil
+	f.mu.Unlock()
+
+	sent, err := f.send(events)
+	if err != nil {
+		f.closeConn()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if unsent := events[sent:]; len(unsent) > 0 {
+		f.pending = append(unsent, f.pending...)
+		f.trim()
+	}
+	if err != nil && !f.failing {
+		fmt.Fprintf(os.Stderr, "fluent: %v, buffering entries\n", err)
+	}
+	f.failing = err != nil
+	return err
+}
+
+// flushWithin flushes the buffered entries, waiting at most timeout for the flush to complete.
+func (f *Forwarder) flushWithin(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		f.Flush()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+// Close sends the buffered entries and closes the connection.
+// Calling it again has no effect.
+func (f *Forwarder) Close() error {
+	var err error
+	f.closeOnce.Do(func() {
+		close(f.done)
+		f.wg.Wait()
+		err = f.Flush()
+		f.sendMu.Lock()
+		defer f.sendMu.Unlock()
+		f.closeConn()
+	})
+	return err
+}
+
+func (f *Forwarder) run() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+		case <-f.flush:
+		}
+		f.Flush()
+	}
+}
+
+// send sends events in batches of consecutive entries of the same tag,
+// returning the number of entries sent. f.sendMu must be held.
+func (f *Forwarder) send(events []event) (int, error) {
+	sent := 0
+	for sent < len(events) {
+		end := sent + 1
+		for end < len(events) && events[end].tag == events[sent].tag {
+			end++
+		}
+		if err := f.sendBatch(events[sent].tag, events[sent:end]); err != nil {
+			return sent, err
+		}
+		sent = end
+	}
+	return sent, nil
+}
+
+// sendBatch sends events as a PackedForward message: [tag, entries, {"size": n, "chunk": id}].
+func (f *Forwarder) sendBatch(tag string, events []event) error {
+	var entries []byte
+	for _, e := range events {
+		entries = append(entries, e.data...)
+	}
+	option := map[string]any{"size": len(events)}
+	var chunk string
+	if f.opts.RequireAck {
+		id := make([]byte, 16)
+		rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+	msg := appendArrayHeader(nil, 3)
+	msg = appendString(msg, tag)
+	msg = appendBinary(msg, entries)
+	msg = appendValue(msg, option)
+
+	c, err := f.connect()
+	if err != nil {
+		return err
+	}
+	c.SetDeadline(time.Now().Add(f.opts.Timeout))
+	if _, err := c.Write(msg); err != nil {
+		return err
+	}
+	if !f.opts.RequireAck {
+		return nil
+	}
+	resp, err := readValue(c.r)
+	if err != nil {
+		return fmt.Errorf("reading acknowledgment: %w", err)
+	}
+	if m, ok := resp.(map[string]any); !ok || m["ack"] != chunk {
+		return errors.New("acknowledgment mismatch")
+	}
+	return nil
+}
+
+// connect returns the current connection, dialing when there is none or the peer closed it.
+func (f *Forwarder) connect() (*conn, error) {
+	if f.conn != nil {
+		select {
+		case <-f.conn.closed:
+			f.closeConn()
+		default:
+			return f.conn, nil
+		}
+	}
+	c, err := net.DialTimeout(f.network, f.address, f.opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	f.conn = &conn{Conn: c, r: bufio.NewReader(c), closed: make(chan struct{})}
+	if !f.opts.RequireAck {
+		go f.conn.watch()
+	}
+	return f.conn, nil
+}
+
+// watch reads, and discards, from the connection until it fails, then marks it closed.
+func (c *conn) watch() {
+	for {
+		if _, err := c.r.Discard(1); err != nil {
+			close(c.closed)
+			return
+		}
+	}
+}
+
+func (f *Forwarder) closeConn() {
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+}
+
+// fluentLogger maps a Forwarder to a Contextual logger interface.
+type fluentLogger struct {
+	forwarder *Forwarder
+	tag       string
+	fields    []any
+}
+
+// NewLogger returns a Contextual logger posting its entries to f. Records hold the message,
+// the level and the fields, and are tagged with the forwarder tag followed by the name of
+// the logger, taken from the named.NameKey field.
+// As the program ends right after Fatal and Panic entries, they are flushed before returning,
+// waiting at most the Timeout of the forwarder.
+func NewLogger(f *Forwarder) loggers.Contextual {
+	return mappers.NewContextualMap(&fluentLogger{forwarder: f, tag: f.opts.Tag})
+}
+
+func (l *fluentLogger) GetUnderlying() any {
+	return l.forwarder
+}
+
+// LevelPrint is a Mapper method
+func (l *fluentLogger) LevelPrint(lev mappers.Level, i ...any) {
+	level, _ := lev.MarshalText()
+	record := make(map[string]any, len(l.fields)/2+2)
+	for i := 0; i+1 < len(l.fields); i = i + 2 {
+		record[fmt.Sprint(l.fields[i])] = l.fields[i+1]
+	}
+	record["message"] = fmt.Sprint(i...)
+	record["level"] = string(level)
+	l.forwarder.Post(l.tag, time.Now(), record)
+	if lev >= mappers.LevelFatal {
+		l.forwarder.flushWithin(l.forwarder.opts.Timeout)
+	}
+}
+
+// LevelPrintf is a Mapper method
+func (l *fluentLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	l.LevelPrint(lev, fmt.Sprintf(format, i...))
+}
+
+// LevelPrintln is a Mapper method
+func (l *fluentLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	l.LevelPrint(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"))
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *fluentLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *fluentLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := fluentLogger{
+		forwarder: l.forwarder,
+		tag:       l.tag,
+		fields:    append(l.fields[:len(l.fields):len(l.fields)], fields...),
+	}
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		if k, ok := fields[i].(string); ok && k == named.NameKey {
+			nl.tag = l.forwarder.opts.Tag + "." + fmt.Sprint(fields[i+1])
+		}
+	}
+	return mappers.NewContextualMap(&nl)
+}
@@ -0,0 +1,264 @@
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+func TestFluentInterface(t *testing.T) {
+	f := New("tcp", "127.0.0.1:0", Options{})
+	defer f.Close()
+	var _ loggers.Contextual = NewLogger(f)
+}
+
+// message is a PackedForward message received by a fakeForwarder.
+type message struct {
+	tag     string
+	entries [][]any
+	option  map[string]any
+}
+
+// fakeForwarder is an in-process forwarder acknowledging the chunks it receives when ack is set.
+type fakeForwarder struct {
+	ln       net.Listener
+	ack      bool
+	messages chan message
+}
+
+func newFakeForwarder(t *testing.T, address string, ack bool) *fakeForwarder {
+	t.Helper()
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	f := fakeForwarder{ln: ln, ack: ack, messages: make(chan message, 100)}
+	t.Cleanup(func() { ln.Close() })
+	go f.serve(t)
+	return &f
+}
+
+func (f *fakeForwarder) serve(t *testing.T) {
+	for {
+		c, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(t, c)
+	}
+}
+
+func (f *fakeForwarder) handle(t *testing.T, c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		v, err := readValue(r)
+		if err != nil {
+			return
+		}
+		a, ok := v.([]any)
+		if !ok || len(a) != 3 {
+			t.Errorf("Message mismatch %v (actual) != [tag, entries, option] (expected)", v)
+			return
+		}
+		m := message{tag: a[0].(string), option: a[2].(map[string]any)}
+		er := bufio.NewReader(bytes.NewReader(a[1].([]byte)))
+		for {
+			e, err := readValue(er)
+			if err != nil {
+				break
+			}
+			m.entries = append(m.entries, e.([]any))
+		}
+		if chunk, ok := m.option["chunk"]; ok && f.ack {
+			c.Write(appendValue(nil, map[string]any{"ack": chunk}))
+		}
+		f.messages <- m
+	}
+}
+
+func (f *fakeForwarder) receive(t *testing.T) message {
+	t.Helper()
+	select {
+	case m := <-f.messages:
+		return m
+	case <-time.After(2 * time.Second):
+		t.Fatalf("No message received")
+		return message{}
+	}
+}
+
+func TestFluentPackedForward(t *testing.T) {
+	fwd := newFakeForwarder(t, "127.0.0.1:0", false)
+	f := New("tcp", fwd.ln.Addr().String(), Options{Tag: "svc", FlushInterval: time.Hour})
+	defer f.Close()
+
+	l := NewLogger(f)
+	l.WithField("n", 1).Info("first")
+	l.Warnf("second %d", 2)
+	db := l.WithFields(named.NameKey, "db", "query", []string{"a", "b"})
+	db.Error("third")
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	m := fwd.receive(t)
+	if m.tag != "svc" || len(m.entries) != 2 || m.option["size"] != int64(2) {
+		t.Fatalf("First batch mismatch %q %d entries %v", m.tag, len(m.entries), m.option)
+	}
+	if _, ok := m.option["chunk"]; ok {
+		t.Errorf("Unexpected chunk option without acknowledgments")
+	}
+	if ts, ok := m.entries[0][0].(time.Time); !ok || time.Since(ts) > time.Minute {
+		t.Errorf("Event time mismatch %v", m.entries[0][0])
+	}
+	record := m.entries[0][1].(map[string]any)
+	if record["message"] != "first" || record["level"] != "info" || record["n"] != int64(1) {
+		t.Errorf("Record mismatch %v", record)
+	}
+	if record := m.entries[1][1].(map[string]any); record["message"] != "second 2" || record["level"] != "warn" {
+		t.Errorf("Record mismatch %v", record)
+	}
+
+	m = fwd.receive(t)
+	if m.tag != "svc.db" || len(m.entries) != 1 {
+		t.Fatalf("Second batch mismatch %q %d entries", m.tag, len(m.entries))
+	}
+	record = m.entries[0][1].(map[string]any)
+	if q, _ := record["query"].([]any); len(q) != 2 || q[1] != "b" || record[named.NameKey] != "db" {
+		t.Errorf("Record mismatch %v", record)
+	}
+}
+
+func TestFluentBatchSize(t *testing.T) {
+	fwd := newFakeForwarder(t, "127.0.0.1:0", false)
+	f := New("tcp", fwd.ln.Addr().String(), Options{BatchSize: 3, FlushInterval: time.Hour})
+	defer f.Close()
+
+	l := NewLogger(f)
+	for i := 0; i < 3; i++ {
+		l.Info(i)
+	}
+	if m := fwd.receive(t); len(m.entries) != 3 {
+		t.Errorf("Batch size mismatch %d (actual) != 3 (expected)", len(m.entries))
+	}
+}
+
+func TestFluentAck(t *testing.T) {
+	fwd := newFakeForwarder(t, "127.0.0.1:0", true)
+	f := New("tcp", fwd.ln.Addr().String(), Options{RequireAck: true, FlushInterval: time.Hour})
+	defer f.Close()
+
+	NewLogger(f).Info("acknowledged")
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if m := fwd.receive(t); m.option["chunk"] == nil {
+		t.Errorf("Missing chunk option %v", m.option)
+	}
+
+	fwd.ack = false
+	f.opts.Timeout = 100 * time.Millisecond
+	NewLogger(f).Info("unacknowledged")
+	if err := f.Flush(); err == nil {
+		t.Errorf("Expected an error without acknowledgment")
+	}
+	fwd.receive(t)
+	if n := len(f.pending); n != 1 {
+		t.Errorf("Pending entries mismatch %d (actual) != 1 (expected)", n)
+	}
+}
+
+func TestFluentBuffering(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	f := New("tcp", address, Options{BufferLimit: 3, FlushInterval: time.Hour, Timeout: time.Second})
+	defer f.Close()
+	l := NewLogger(f)
+	for i := 0; i < 5; i++ {
+		l.Info(i)
+		if err := f.Flush(); err == nil {
+			t.Fatalf("Expected an error while the forwarder is down")
+		}
+	}
+	if n := f.Dropped(); n != 2 {
+		t.Errorf("Dropped mismatch %d (actual) != 2 (expected)", n)
+	}
+
+	fwd := newFakeForwarder(t, address, false)
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	m := fwd.receive(t)
+	if len(m.entries) != 3 {
+		t.Fatalf("Buffered entries mismatch %d (actual) != 3 (expected)", len(m.entries))
+	}
+	if record := m.entries[0][1].(map[string]any); record["message"] != "2" {
+		t.Errorf("Oldest entry mismatch %v (actual) != 2 (expected)", record["message"])
+	}
+}
+
+func TestFluentMsgpackRoundTrip(t *testing.T) {
+	values := []any{nil, true, false, int64(0), int64(-1), int64(-100), int64(1000), int64(-40000),
+		int64(1 << 40), 1.5, "", "short", string(bytes.Repeat([]byte("x"), 300)), []byte{1, 2}}
+	for _, v := range values {
+		b := appendValue(nil, v)
+		r, err := readValue(bufio.NewReader(bytes.NewReader(b)))
+		if err != nil {
+			t.Errorf("Decoding %v failed: %v", v, err)
+			continue
+		}
+		if rb, ok := r.([]byte); ok {
+			if !bytes.Equal(rb, v.([]byte)) {
+				t.Errorf("Round trip mismatch %v (actual) != %v (expected)", r, v)
+			}
+		} else if r != v {
+			t.Errorf("Round trip mismatch %v (actual) != %v (expected)", r, v)
+		}
+	}
+}
+
+func TestFluentMsgpackNilPointers(t *testing.T) {
+	var err *url.Error
+	var u *url.URL
+	for _, v := range []any{err, u} {
+		if b := appendValue(nil, v); !bytes.Equal(b, []byte{0xc0}) {
+			t.Errorf("Encoding of %T mismatch %x (actual) != c0 (expected)", v, b)
+		}
+	}
+}
+
+func TestFluentCloseTwice(t *testing.T) {
+	f := New("tcp", "127.0.0.1:0", Options{})
+	f.Close()
+	if err := f.Close(); err != nil {
+		t.Errorf("Second Close failed: %v", err)
+	}
+}
+
+func TestFluentPanicFlush(t *testing.T) {
+	fwd := newFakeForwarder(t, "127.0.0.1:0", false)
+	f := New("tcp", fwd.ln.Addr().String(), Options{Tag: "svc", FlushInterval: time.Hour})
+	defer f.Close()
+
+	func() {
+		defer func() { recover() }()
+		NewLogger(f).Panic("crash")
+	}()
+	// No Flush: the entry must have been sent before Panic returned.
+	m := fwd.receive(t)
+	if record := m.entries[0][1].(map[string]any); record["message"] != "crash" || record["level"] != "panic" {
+		t.Errorf("Record mismatch %v (actual) != crash at panic level (expected)", record)
+	}
+}
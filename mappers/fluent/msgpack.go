@@ -0,0 +1,319 @@
+package fluent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+// isNilPointer reports whether v is a nil pointer, whose methods may panic.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// appendValue appends the MessagePack encoding of v to b. Values without a MessagePack
+// counterpart are encoded as their fmt.Sprint string, times as Fluentd EventTime.
+// Errors and Stringers held by nil pointers are encoded as nil.
+func appendValue(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendInt(b, int64(v))
+	case int8:
+		return appendInt(b, int64(v))
+	case int16:
+		return appendInt(b, int64(v))
+	case int32:
+		return appendInt(b, int64(v))
+	case int64:
+		return appendInt(b, v)
+	case uint:
+		return appendUint(b, uint64(v))
+	case uint8:
+		return appendUint(b, uint64(v))
+	case uint16:
+		return appendUint(b, uint64(v))
+	case uint32:
+		return appendUint(b, uint64(v))
+	case uint64:
+		return appendUint(b, v)
+	case float32:
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(v))
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v))
+	case string:
+		return appendString(b, v)
+	case []byte:
+		return appendBinary(b, v)
+	case time.Time:
+		return appendEventTime(b, v)
+	case time.Duration:
+		return appendString(b, v.String())
+	case error:
+		if isNilPointer(v) {
+			return append(b, 0xc0)
+		}
+		return appendString(b, v.Error())
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return append(b, 0xc0)
+		}
+		return appendString(b, v.String())
+	case []any:
+		b = appendArrayHeader(b, len(v))
+		for _, e := range v {
+			b = appendValue(b, e)
+		}
+		return b
+	case map[string]any:
+		b = appendMapHeader(b, len(v))
+		for k, e := range v {
+			b = appendValue(appendString(b, k), e)
+		}
+		return b
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		b = appendArrayHeader(b, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			b = appendValue(b, rv.Index(i).Interface())
+		}
+		return b
+	case reflect.Map:
+		b = appendMapHeader(b, rv.Len())
+		for it := rv.MapRange(); it.Next(); {
+			b = appendValue(appendString(b, fmt.Sprint(it.Key().Interface())), it.Value().Interface())
+		}
+		return b
+	}
+	return appendString(b, fmt.Sprint(v))
+}
+
+func appendInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendBinary(b []byte, p []byte) []byte {
+	n := len(p)
+	switch {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, p...)
+}
+
+func appendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+}
+
+func appendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+}
+
+// appendEventTime appends t as a Fluentd EventTime, the extension type 0 holding
+// the seconds and nanoseconds since the epoch.
+func appendEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// readValue decodes a MessagePack value from r. Maps are decoded as map[string]any,
+// binaries as []byte and EventTime as time.Time. It is used to read acknowledgments.
+func readValue(r *bufio.Reader) (any, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return readMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return readArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return readString(r, int(c&0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	case 0xca:
+		n, err := readUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := readUint(r, 1<<(c-0xcc))
+		return int64(n), err
+	case 0xd0:
+		n, err := readUint(r, 1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := readUint(r, 2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := readUint(r, 4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := readUint(r, 8)
+		return int64(n), err
+	case 0xd7:
+		b := make([]byte, 9)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		if b[0] != 0x00 {
+			return nil, fmt.Errorf("msgpack: unsupported extension type %d", b[0])
+		}
+		return time.Unix(int64(binary.BigEndian.Uint32(b[1:5])), int64(binary.BigEndian.Uint32(b[5:]))), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := readUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return readString(r, int(n))
+	case 0xdc, 0xdd:
+		n, err := readUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readArray(r, int(n))
+	case 0xde, 0xdf:
+		n, err := readUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMap(r, int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", c)
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	b := make([]byte, 8)
+	if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func readString(r *bufio.Reader, n int) (string, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return string(b), err
+}
+
+func readArray(r *bufio.Reader, n int) ([]any, error) {
+	a := make([]any, n)
+	for i := range a {
+		v, err := readValue(r)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func readMap(r *bufio.Reader, n int) (map[string]any, error) {
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, err := readValue(r)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errors.New("msgpack: map key is not a string")
+		}
+		if m[key], err = readValue(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,280 @@
+// Package gelf maps a Contextual logger to GELF 1.1 messages sent to Graylog over UDP or TCP.
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/syslog"
+	"github.com/marcaudefroy/loggers/output"
+)
+
+// Compression is the compression of UDP messages.
+type Compression int
+
+const (
+	// NoCompression sends UDP messages as plain JSON.
+	NoCompression Compression = iota
+	// Gzip compresses UDP messages with gzip.
+	Gzip
+	// Zlib compresses UDP messages with zlib.
+	Zlib
+)
+
+const (
+	// EmptyMessage replaces empty short messages, which GELF does not allow.
+	EmptyMessage = "-"
+	// DefaultChunkSize is the maximum size of a UDP datagram, fitting a typical ethernet MTU.
+	DefaultChunkSize = 1420
+	// maxChunks is the maximum number of chunks of a message accepted by Graylog.
+	maxChunks = 128
+	// chunkHeaderSize is the size of the magic bytes, message ID, sequence number and count.
+	chunkHeaderSize = 12
+)
+
+// Options configures a Writer. The zero value is usable.
+type Options struct {
+	// Host is the host of the messages, os.Hostname by default.
+	Host string
+	// Compression of UDP messages, TCP messages are never compressed.
+	Compression Compression
+	// ChunkSize is the maximum size of a UDP datagram, DefaultChunkSize by default.
+	ChunkSize int
+	// Timeout bounds dialing and each write, 0 means no timeout.
+	Timeout time.Duration
+}
+
+// Message is a GELF 1.1 message.
+type Message struct {
+	Host         string
+	ShortMessage string
+	FullMessage  string
+	Timestamp    time.Time
+	Level        int
+	// Extra are the additional fields, without their _ prefix.
+	Extra map[string]any
+}
+
+// MarshalJSON encodes m in the GELF 1.1 format, prefixing the additional fields with an underscore.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	v := make(map[string]any, len(m.Extra)+6)
+	for k, value := range m.Extra {
+		v[fieldName(k)] = fieldValue(value)
+	}
+	v["version"] = "1.1"
+	v["host"] = m.Host
+	v["short_message"] = m.ShortMessage
+	if m.FullMessage != "" {
+		v["full_message"] = m.FullMessage
+	}
+	v["timestamp"] = float64(m.Timestamp.UnixMilli()) / 1000
+	v["level"] = m.Level
+	return json.Marshal(v)
+}
+
+var invalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
+
+// fieldName returns key as a GELF additional field name. The forbidden _id becomes _id_.
+func fieldName(key string) string {
+	name := "_" + invalidFieldChars.ReplaceAllString(key, "_")
+	if name == "_id" {
+		name = "_id_"
+	}
+	return name
+}
+
+// fieldValue returns value as a number or a string, the only types GELF accepts.
+// NaN and infinite floats, which JSON cannot represent, are returned as strings.
+func fieldValue(value any) any {
+	switch v := value.(type) {
+	case float32:
+		return fieldValue(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Writer sends GELF messages. UDP messages larger than the chunk size are chunked,
+// TCP messages are delimited by a null byte. It is safe for concurrent use.
+type Writer struct {
+	conn *output.Network
+	udp  bool
+	opts Options
+}
+
+// Dial returns a Writer for the given network, "udp" or "tcp", and address.
+func Dial(network, address string, opts Options) (*Writer, error) {
+	w := Writer{opts: opts}
+	switch network {
+	case "udp", "udp4", "udp6":
+		w.udp = true
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("gelf: unsupported network %q", network)
+	}
+	if w.opts.Host == "" {
+		w.opts.Host, _ = os.Hostname()
+	}
+	if w.opts.ChunkSize <= chunkHeaderSize {
+		w.opts.ChunkSize = DefaultChunkSize
+	}
+	w.conn = output.NewNetwork(network, address, opts.Timeout)
+	return &w, nil
+}
+
+// Close closes the connection. A later message reconnects.
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
+
+// Write sends m with the host of the Writer if it has none, and EmptyMessage
+// as short message if it is blank. m is not modified.
+func (w *Writer) Write(m *Message) error {
+	c := *m
+	if c.Host == "" {
+		c.Host = w.opts.Host
+	}
+	if strings.TrimSpace(c.ShortMessage) == "" {
+		c.ShortMessage = EmptyMessage
+	}
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return err
+	}
+	if !w.udp {
+		_, err = w.conn.Write(append(b, 0))
+		return err
+	}
+	if b, err = w.compress(b); err != nil {
+		return err
+	}
+	if len(b) <= w.opts.ChunkSize {
+		_, err = w.conn.Write(b)
+		return err
+	}
+	return w.writeChunks(b)
+}
+
+func (w *Writer) compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var c interface {
+		Write([]byte) (int, error)
+		Close() error
+	}
+	switch w.opts.Compression {
+	case Gzip:
+		c = gzip.NewWriter(&buf)
+	case Zlib:
+		c = zlib.NewWriter(&buf)
+	default:
+		return b, nil
+	}
+	if _, err := c.Write(b); err != nil {
+		return nil, err
+	}
+	if err := c.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeChunks sends b as chunks sharing a random message ID.
+func (w *Writer) writeChunks(b []byte) error {
+	size := w.opts.ChunkSize - chunkHeaderSize
+	count := (len(b) + size - 1) / size
+	if count > maxChunks {
+		return errors.New("gelf: message too large to be chunked")
+	}
+	header := make([]byte, chunkHeaderSize)
+	header[0], header[1] = 0x1e, 0x0f
+	binary.BigEndian.PutUint64(header[2:10], rand.Uint64())
+	header[11] = byte(count)
+
+	chunk := make([]byte, 0, w.opts.ChunkSize)
+	for i := 0; i < count; i++ {
+		header[10] = byte(i)
+		end := min((i+1)*size, len(b))
+		chunk = append(append(chunk[:0], header...), b[i*size:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gelfLogger maps a Writer to a Contextual logger interface.
+type gelfLogger struct {
+	writer *Writer
+	fields []any
+}
+
+// NewLogger returns a Contextual logger sending its entries to w.
+// Multi-line messages are sent with their first line as short message and in full as full message.
+// Write errors are reported on stderr, as there is nowhere else to log them.
+func NewLogger(w *Writer) loggers.Contextual {
+	return mappers.NewContextualMap(&gelfLogger{writer: w})
+}
+
+func (l *gelfLogger) GetUnderlying() any {
+	return l.writer
+}
+
+// LevelPrint is a Mapper method
+func (l *gelfLogger) LevelPrint(lev mappers.Level, i ...any) {
+	msg := fmt.Sprint(i...)
+	m := Message{ShortMessage: msg, Timestamp: time.Now(), Level: syslog.Severity(lev)}
+	if first, _, multiline := strings.Cut(msg, "\n"); multiline {
+		m.ShortMessage, m.FullMessage = first, msg
+	}
+	if len(l.fields) > 1 {
+		m.Extra = make(map[string]any, len(l.fields)/2)
+		for i := 0; i+1 < len(l.fields); i = i + 2 {
+			m.Extra[fmt.Sprint(l.fields[i])] = l.fields[i+1]
+		}
+	}
+	if err := l.writer.Write(&m); err != nil {
+		fmt.Fprintf(os.Stderr, "gelf: %v\n", err)
+	}
+}
+
+// LevelPrintf is a Mapper method
+func (l *gelfLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	l.LevelPrint(lev, fmt.Sprintf(format, i...))
+}
+
+// LevelPrintln is a Mapper method
+func (l *gelfLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	l.LevelPrint(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"))
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *gelfLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *gelfLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := gelfLogger{writer: l.writer, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...)}
+	return mappers.NewContextualMap(&nl)
+}
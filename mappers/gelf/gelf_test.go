@@ -0,0 +1,225 @@
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+func TestGELFInterface(t *testing.T) {
+	var _ loggers.Contextual = NewLogger(&Writer{})
+}
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	return b[:n]
+}
+
+func decode(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("Unmarshal of %q failed: %v", b, err)
+	}
+	return m
+}
+
+func TestGELFUDP(t *testing.T) {
+	conn := listenUDP(t)
+	w, err := Dial("udp", conn.LocalAddr().String(), Options{Host: "host"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	NewLogger(w).WithFields("user id", "bob", "id", 7, "ok", true).Warnf("disk %d%% full\nsee %s", 90, "/var")
+	m := decode(t, readDatagram(t, conn))
+	expected := map[string]any{
+		"version":       "1.1",
+		"host":          "host",
+		"short_message": "disk 90% full",
+		"full_message":  "disk 90% full\nsee /var",
+		"level":         float64(4),
+		"_user_id":      "bob",
+		"_id_":          float64(7),
+		"_ok":           "true",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("Field %s mismatch %v (actual) != %v (expected)", k, m[k], v)
+		}
+	}
+	if ts, _ := m["timestamp"].(float64); time.Since(time.UnixMilli(int64(ts*1000))) > time.Minute {
+		t.Errorf("Timestamp mismatch %v", m["timestamp"])
+	}
+}
+
+func TestGELFNonFiniteFloats(t *testing.T) {
+	conn := listenUDP(t)
+	w, err := Dial("udp", conn.LocalAddr().String(), Options{Host: "host"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	NewLogger(w).WithFields("nan", math.NaN(), "inf", math.Inf(-1), "ratio", float32(0.5)).Info("floats")
+	m := decode(t, readDatagram(t, conn))
+	expected := map[string]any{"_nan": "NaN", "_inf": "-Inf", "_ratio": 0.5}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("Field %s mismatch %v (actual) != %v (expected)", k, m[k], v)
+		}
+	}
+}
+
+func TestGELFWriteEmptyMessage(t *testing.T) {
+	conn := listenUDP(t)
+	w, err := Dial("udp", conn.LocalAddr().String(), Options{Host: "host"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	msg := Message{Timestamp: time.Now(), Level: 6}
+	if err := w.Write(&msg); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	m := decode(t, readDatagram(t, conn))
+	if m["short_message"] != EmptyMessage || m["host"] != "host" {
+		t.Errorf("Message mismatch %v (actual) != short_message %q and host %q (expected)", m, EmptyMessage, "host")
+	}
+	if msg.Host != "" || msg.ShortMessage != "" {
+		t.Errorf("Write must not modify its message, got %+v", msg)
+	}
+}
+
+func TestGELFCompression(t *testing.T) {
+	readers := map[Compression]func(io.Reader) (io.Reader, error){
+		Gzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		Zlib: func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	}
+	for c, newReader := range readers {
+		conn := listenUDP(t)
+		w, err := Dial("udp", conn.LocalAddr().String(), Options{Compression: c})
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		NewLogger(w).Error("compressed")
+		w.Close()
+
+		r, err := newReader(bytes.NewReader(readDatagram(t, conn)))
+		if err != nil {
+			t.Fatalf("Compression %d: reader failed: %v", c, err)
+		}
+		b, _ := io.ReadAll(r)
+		if m := decode(t, b); m["short_message"] != "compressed" || m["level"] != float64(3) {
+			t.Errorf("Compression %d: message mismatch %v", c, m)
+		}
+	}
+}
+
+func TestGELFChunking(t *testing.T) {
+	conn := listenUDP(t)
+	w, err := Dial("udp", conn.LocalAddr().String(), Options{ChunkSize: 100})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	msg := strings.Repeat("x", 1000)
+	NewLogger(w).Info(msg)
+
+	var id []byte
+	var count int
+	chunks := map[int][]byte{}
+	for count == 0 || len(chunks) < count {
+		b := readDatagram(t, conn)
+		if len(b) > 100 || b[0] != 0x1e || b[1] != 0x0f {
+			t.Fatalf("Chunk header mismatch %x, length %d", b[:2], len(b))
+		}
+		if id == nil {
+			id = b[2:10]
+		} else if !bytes.Equal(id, b[2:10]) {
+			t.Fatalf("Chunk id mismatch %x (actual) != %x (expected)", b[2:10], id)
+		}
+		count = int(b[11])
+		chunks[int(b[10])] = b[12:]
+	}
+	var full []byte
+	for i := 0; i < count; i++ {
+		full = append(full, chunks[i]...)
+	}
+	if m := decode(t, full); m["short_message"] != msg {
+		t.Errorf("Reassembled message mismatch %v", m["short_message"])
+	}
+
+	if err := w.Write(&Message{ShortMessage: strings.Repeat("x", 100*maxChunks)}); err == nil {
+		t.Errorf("Expected an error for a message needing more than %d chunks", maxChunks)
+	}
+}
+
+func TestGELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	w, err := Dial("tcp", ln.Addr().String(), Options{Host: "host", Compression: Gzip})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+	l := NewLogger(w)
+	l.Info("first")
+	l.WithField("n", 2).Debug("second")
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, expected := range []string{"first", "second"} {
+		b, err := r.ReadBytes(0)
+		if err != nil {
+			t.Fatalf("ReadBytes failed: %v", err)
+		}
+		if m := decode(t, b[:len(b)-1]); m["short_message"] != expected {
+			t.Errorf("Message mismatch %v (actual) != %q (expected)", m["short_message"], expected)
+		}
+	}
+}
+
+func TestGELFUnsupportedNetwork(t *testing.T) {
+	if _, err := Dial("unix", "/dev/null", Options{}); err == nil {
+		t.Errorf("Expected an error for the unix network")
+	}
+}
@@ -0,0 +1,193 @@
+// Package journald maps a Contextual logger to systemd-journald entries sent with the native journal protocol.
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/syslog"
+)
+
+// DefaultSocket is the path of the journald native protocol socket.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// maxFieldName is the maximum length of a journal field name.
+const maxFieldName = 64
+
+// Options configures a Writer. The zero value is usable.
+type Options struct {
+	// Identifier is the SYSLOG_IDENTIFIER of the entries, the base name of the executable by default.
+	Identifier string
+	// Caller adds the CODE_FILE, CODE_LINE and CODE_FUNC fields of the logging call.
+	Caller bool
+}
+
+// Writer sends entries to journald. It is safe for concurrent use.
+// Its socket is not connected: every entry is addressed to the journald socket,
+// so that sending resumes on its own after journald restarts.
+type Writer struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+	opts Options
+}
+
+// Dial returns a Writer sending to the journald socket at path, DefaultSocket when empty.
+// It fails if there is no socket at path.
+func Dial(path string, opts Options) (*Writer, error) {
+	if path == "" {
+		path = DefaultSocket
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	return &Writer{conn: conn, addr: &net.UnixAddr{Name: path, Net: "unixgram"}, opts: opts}, nil
+}
+
+// Close closes the socket of the Writer.
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
+
+// Send sends an entry made of the given fields, a list of key/value pairs.
+// Keys are sanitized into valid journal field names, see FieldName.
+func (w *Writer) Send(fields ...any) error {
+	var b bytes.Buffer
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		name := FieldName(fmt.Sprint(fields[i]))
+		if name == "" {
+			continue
+		}
+		appendField(&b, name, fmt.Sprint(fields[i+1]))
+	}
+	return send(w.conn, w.addr, b.Bytes())
+}
+
+// appendField encodes a field with the native protocol: NAME=value for single line values,
+// and NAME, the little endian 64 bits length and the value otherwise.
+func appendField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		b.WriteByte('=')
+		b.WriteString(value)
+	} else {
+		b.WriteByte('\n')
+		binary.Write(b, binary.LittleEndian, uint64(len(value)))
+		b.WriteString(value)
+	}
+	b.WriteByte('\n')
+}
+
+// FieldName returns key as a journal field name: upper case letters, digits and underscores,
+// not starting with an underscore nor a digit, at most 64 characters.
+// It returns an empty string if nothing is left of key.
+func FieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "F" + name
+	}
+	if len(name) > maxFieldName {
+		name = name[:maxFieldName]
+	}
+	return name
+}
+
+// userFieldPrefix prefixes the names of the fields of a logger which would otherwise
+// duplicate the fields set by the logger itself.
+const userFieldPrefix = "FIELD_"
+
+// userFieldName returns the journal field name of the key of a logger field.
+func userFieldName(key string) string {
+	name := FieldName(key)
+	switch {
+	case name == "MESSAGE", name == "PRIORITY", name == "SYSLOG_IDENTIFIER", strings.HasPrefix(name, "CODE_"):
+		name = userFieldPrefix + name
+		if len(name) > maxFieldName {
+			name = name[:maxFieldName]
+		}
+	}
+	return name
+}
+
+// journaldLogger maps a Writer to a Contextual logger interface.
+type journaldLogger struct {
+	writer *Writer
+	fields []any
+}
+
+// NewLogger returns a Contextual logger sending its entries to w.
+// Each field becomes a journal field, see FieldName. Fields named as the MESSAGE, PRIORITY,
+// SYSLOG_IDENTIFIER and CODE_* fields set by the logger are prefixed with FIELD_.
+// Write errors are reported on stderr, as there is nowhere else to log them.
+func NewLogger(w *Writer) loggers.Contextual {
+	return mappers.NewContextualMap(&journaldLogger{writer: w})
+}
+
+func (l *journaldLogger) GetUnderlying() any {
+	return l.writer
+}
+
+// LevelPrint is a Mapper method
+func (l *journaldLogger) LevelPrint(lev mappers.Level, i ...any) {
+	fields := []any{
+		"MESSAGE", fmt.Sprint(i...),
+		"PRIORITY", syslog.Severity(lev),
+		"SYSLOG_IDENTIFIER", l.writer.opts.Identifier,
+	}
+	if l.writer.opts.Caller {
+		if f, ok := mappers.Caller(); ok {
+			fields = append(fields, "CODE_FILE", f.File, "CODE_LINE", strconv.Itoa(f.Line), "CODE_FUNC", f.Function)
+		}
+	}
+	for i := 0; i+1 < len(l.fields); i = i + 2 {
+		fields = append(fields, userFieldName(fmt.Sprint(l.fields[i])), l.fields[i+1])
+	}
+	if err := l.writer.Send(fields...); err != nil {
+		fmt.Fprintf(os.Stderr, "journald: %v\n", err)
+	}
+}
+
+// LevelPrintf is a Mapper method
+func (l *journaldLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	l.LevelPrint(lev, fmt.Sprintf(format, i...))
+}
+
+// LevelPrintln is a Mapper method
+func (l *journaldLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	l.LevelPrint(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"))
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *journaldLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *journaldLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := journaldLogger{writer: l.writer, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...)}
+	return mappers.NewContextualMap(&nl)
+}
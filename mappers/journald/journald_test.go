@@ -0,0 +1,139 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+func TestJournaldInterface(t *testing.T) {
+	var _ loggers.Contextual = NewLogger(&Writer{})
+}
+
+// listen starts a stand-in of the journald socket.
+func listen(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, path
+}
+
+// parse decodes a native protocol entry.
+func parse(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			t.Fatalf("Invalid entry %q", b)
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			end := bytes.IndexByte(b, '\n')
+			fields[name] = string(b[i+1 : end])
+			b = b[end+1:]
+			continue
+		}
+		n := binary.LittleEndian.Uint64(b[i+1 : i+9])
+		fields[name] = string(b[i+9 : i+9+int(n)])
+		b = b[i+9+int(n)+1:]
+	}
+	return fields
+}
+
+func receive(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, 65536)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return parse(t, b[:n])
+}
+
+func TestJournaldEntry(t *testing.T) {
+	conn, path := listen(t)
+	w, err := Dial(path, Options{Identifier: "app", Caller: true})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	NewLogger(w).WithFields("request-id", 42, "_private", "x", "9lives", true, "message", "forged", "code.file", "x.go").Errorf("failed:\n%s", "trace")
+	fields := receive(t, conn)
+
+	expected := map[string]string{
+		"MESSAGE":           "failed:\ntrace",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "app",
+		"REQUEST_ID":        "42",
+		"PRIVATE":           "x",
+		"F9LIVES":           "true",
+		"FIELD_MESSAGE":     "forged",
+		"FIELD_CODE_FILE":   "x.go",
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("Field %s mismatch %q (actual) != %q (expected)", k, fields[k], v)
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journald_test.go") || fields["CODE_LINE"] == "" {
+		t.Errorf("Caller fields mismatch %q:%q", fields["CODE_FILE"], fields["CODE_LINE"])
+	}
+}
+
+func TestJournaldRestart(t *testing.T) {
+	conn, path := listen(t)
+	w, err := Dial(path, Options{Identifier: "app"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+	l := NewLogger(w)
+
+	l.Info("before")
+	if m := receive(t, conn)["MESSAGE"]; m != "before" {
+		t.Errorf("Message mismatch %q (actual) != %q (expected)", m, "before")
+	}
+
+	// journald restarting removes its socket and creates a new one at the same path.
+	conn.Close()
+	os.Remove(path)
+	conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram failed: %v", err)
+	}
+	defer conn.Close()
+
+	l.Info("after")
+	if m := receive(t, conn)["MESSAGE"]; m != "after" {
+		t.Errorf("Message mismatch %q (actual) != %q (expected)", m, "after")
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user.id":               "USER_ID",
+		"__x":                   "X",
+		"1st":                   "F1ST",
+		"___":                   "",
+		strings.Repeat("a", 70): strings.Repeat("A", 64),
+	}
+	for key, expected := range tests {
+		if name := FieldName(key); name != expected {
+			t.Errorf("FieldName(%q) mismatch %q (actual) != %q (expected)", key, name, expected)
+		}
+	}
+}
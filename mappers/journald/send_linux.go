@@ -0,0 +1,36 @@
+//go:build linux
+
+package journald
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// send writes the entry as a datagram. Entries too large for a datagram are written
+// to a sealed memfd whose descriptor is sent instead, as expected by journald.
+func send(conn *net.UnixConn, addr *net.UnixAddr, entry []byte) error {
+	_, _, err := conn.WriteMsgUnix(entry, nil, addr)
+	if err == nil || !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	fd, err := unix.MemfdCreate("journald", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	f := os.NewFile(uintptr(fd), "journald")
+	defer f.Close()
+	if _, err := f.Write(entry); err != nil {
+		return err
+	}
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return err
+	}
+	_, _, err = conn.WriteMsgUnix(nil, unix.UnixRights(int(f.Fd())), addr)
+	return err
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package journald
+
+import (
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJournaldMemfd(t *testing.T) {
+	conn, path := listen(t)
+	w, err := Dial(path, Options{Identifier: "app"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	large := strings.Repeat("x", 4<<20)
+	errs := make(chan error, 1)
+	go func() { errs <- w.Send("MESSAGE", large) }()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(make([]byte, 1), oob)
+	if err != nil {
+		t.Fatalf("ReadMsgUnix failed: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Expected a file descriptor, got %v %v", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("Expected a file descriptor, got %v %v", fds, err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "memfd")
+	defer f.Close()
+	f.Seek(0, io.SeekStart)
+	b, _ := io.ReadAll(f)
+
+	if fields := parse(t, b); fields["MESSAGE"] != large {
+		t.Errorf("Large message mismatch, got %d bytes", len(fields["MESSAGE"]))
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package journald
+
+import "net"
+
+// send writes the entry as a datagram. journald only runs on Linux, so large entries
+// are not handed over with a memfd on other platforms.
+func send(conn *net.UnixConn, addr *net.UnixAddr, entry []byte) error {
+	_, _, err := conn.WriteMsgUnix(entry, nil, addr)
+	return err
+}
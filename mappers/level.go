@@ -0,0 +1,97 @@
+package mappers
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+type (
+	// Leveler provides a minimum log Level.
+	Leveler interface {
+		Level() Level
+	}
+
+	// LevelSetter is implemented by loggers whose minimum level can be changed at runtime.
+	LevelSetter interface {
+		Leveler
+		SetLevel(Level)
+	}
+
+	// LevelVar is a Level that can be read and changed concurrently.
+	// Its zero value is LevelDebug.
+	LevelVar struct {
+		v atomic.Uint32
+	}
+)
+
+var levelNames = [...]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+	LevelFatal: "fatal",
+	LevelPanic: "panic",
+}
+
+// ParseLevel returns the Level named s, ignoring case. "warning" is accepted for LevelWarn.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		return LevelWarn, nil
+	}
+	for l, n := range levelNames {
+		if n == name {
+			return Level(l), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// Level returns l, so that a fixed Level is a Leveler.
+func (l Level) Level() Level {
+	return l
+}
+
+// MarshalText returns the lower case name of the level.
+func (l Level) MarshalText() ([]byte, error) {
+	if int(l) >= len(levelNames) {
+		return nil, fmt.Errorf("unknown log level %d", l)
+	}
+	return []byte(levelNames[l]), nil
+}
+
+// UnmarshalText parses a level name, see ParseLevel.
+func (l *Level) UnmarshalText(text []byte) error {
+	lev, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lev
+	return nil
+}
+
+// NewLevelVar returns a LevelVar set to l.
+func NewLevelVar(l Level) *LevelVar {
+	var v LevelVar
+	v.Set(l)
+	return &v
+}
+
+// Level returns the current level.
+func (v *LevelVar) Level() Level {
+	return Level(v.v.Load())
+}
+
+// Set changes the current level.
+func (v *LevelVar) Set(l Level) {
+	v.v.Store(uint32(l))
+}
+
+func (v *LevelVar) String() string {
+	l := v.Level()
+	if int(l) >= len(levelNames) {
+		return fmt.Sprintf("LevelVar(Level(%d))", l)
+	}
+	return fmt.Sprintf("LevelVar(%s)", levelNames[l])
+}
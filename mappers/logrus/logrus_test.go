@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+	"github.com/marcaudefroy/loggers/mappers/preset"
 	"github.com/sirupsen/logrus"
 )
 
@@ -105,3 +107,28 @@ func newBufferedLogrusLog() (loggers.Contextual, *bytes.Buffer) {
 	l.Level = logrus.DebugLevel
 	return NewLogger(l), bb
 }
+
+func TestLogrusPresets(t *testing.T) {
+	var b bytes.Buffer
+	l := NewProduction(preset.Output(&b))
+	l.Debug("hidden")
+	l.Info("started")
+
+	expectedMatch := `^\{"caller":"logrus/logrus_test.go:\d+","level":"info","msg":"started","time":"[^"]+"\}` + "\n$"
+	actual := b.String()
+	if ok, _ := regexp.MatchString(expectedMatch, actual); !ok {
+		t.Errorf("Log output mismatch %s (actual) != %s (expected)", actual, expectedMatch)
+	}
+
+	b.Reset()
+	l = NewDevelopment(preset.Output(&b), preset.Color(console.ColorNever))
+	l.Debug("shown")
+	l.Warn("careful")
+
+	expectedMatch = `(?s)^time="[\d:.]+" level=debug msg=shown caller="logrus/logrus_test.go:\d+"\n` +
+		`time="[\d:.]+" level=warning msg=careful caller="logrus/logrus_test.go:\d+" stack="github.com/marcaudefroy/loggers/mappers/logrus.TestLogrusPresets\\n\\t`
+	actual = b.String()
+	if ok, _ := regexp.MatchString(expectedMatch, actual); !ok {
+		t.Errorf("Log output mismatch %s (actual) != %s (expected)", actual, expectedMatch)
+	}
+}
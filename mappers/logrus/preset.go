@@ -0,0 +1,38 @@
+package logrus
+
+import (
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+	"github.com/marcaudefroy/loggers/mappers/preset"
+	"github.com/sirupsen/logrus"
+)
+
+// NewProduction returns a logger set up for production, see preset.Production.
+// Entries are written with a logrus.JSONFormatter and an RFC 3339 time with nanoseconds.
+func NewProduction(opts ...preset.Option) *mappers.LevelFilter {
+	c := preset.Production(opts...)
+	l := logrus.New()
+	l.Out = c.Output
+	l.Level = logrus.DebugLevel
+	l.Formatter = &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	return c.Wrap(NewLogger(l))
+}
+
+// NewDevelopment returns a logger set up for development, see preset.Development.
+// Entries are written with a logrus.TextFormatter using a short local time, colored on terminals.
+func NewDevelopment(opts ...preset.Option) *mappers.LevelFilter {
+	c := preset.Development(opts...)
+	color := c.UseColor()
+	l := logrus.New()
+	l.Out = c.Output
+	l.Level = logrus.DebugLevel
+	l.Formatter = &logrus.TextFormatter{
+		ForceColors:     color,
+		DisableColors:   !color,
+		FullTimestamp:   true,
+		TimestampFormat: console.DefaultTimeLayout,
+	}
+	return c.Wrap(NewLogger(l))
+}
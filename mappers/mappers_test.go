@@ -1,7 +1,11 @@
 package mappers
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/marcaudefroy/loggers"
 )
@@ -16,3 +20,141 @@ func TestInterface(t *testing.T) {
 	var _ LevelMapper = &ContextualMap{}
 	var _ loggers.Contextual = &ContextualMap{}
 }
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"debug", "Info", "WARN", "warning", "error", "fatal", "panic"} {
+		l, err := ParseLevel(name)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) failed: %v", name, err)
+			continue
+		}
+		text, _ := l.MarshalText()
+		if !strings.HasPrefix(strings.ToLower(name), string(text)) {
+			t.Errorf("Level name mismatch %s (actual) for %s", text, name)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel must fail on unknown names")
+	}
+}
+
+func TestLevelVarString(t *testing.T) {
+	v := NewLevelVar(LevelWarn)
+	if s := v.String(); s != "LevelVar(warn)" {
+		t.Errorf("LevelVar mismatch %q (actual) != %q (expected)", s, "LevelVar(warn)")
+	}
+	v.Set(Level(42))
+	if s := v.String(); s != "LevelVar(Level(42))" {
+		t.Errorf("LevelVar mismatch %q (actual) != %q (expected)", s, "LevelVar(Level(42))")
+	}
+}
+
+type recordMapper struct {
+	levels *[]Level
+	fields []any
+}
+
+func (r *recordMapper) LevelPrint(lev Level, _ ...any) { *r.levels = append(*r.levels, lev) }
+func (r *recordMapper) LevelPrintf(lev Level, _ string, _ ...any) {
+	*r.levels = append(*r.levels, lev)
+}
+func (r *recordMapper) LevelPrintln(lev Level, _ ...any) { *r.levels = append(*r.levels, lev) }
+func (r *recordMapper) WithField(key string, value any) loggers.Contextual {
+	return r.WithFields(key, value)
+}
+func (r *recordMapper) WithFields(fields ...any) loggers.Contextual {
+	return NewContextualMap(&recordMapper{levels: r.levels, fields: append(r.fields, fields...)})
+}
+
+func TestLevelFilter(t *testing.T) {
+	var levels []Level
+	f := NewLevelFilter(NewContextualMap(&recordMapper{levels: &levels}), NewLevelVar(LevelWarn))
+	child := f.WithField("k", "v")
+
+	f.Info("dropped")
+	child.Errorf("kept %d", 1)
+	f.SetLevel(LevelDebug)
+	child.Debugln("kept")
+
+	if len(levels) != 2 || levels[0] != LevelError || levels[1] != LevelDebug {
+		t.Errorf("Filtered levels mismatch %v (actual) != [ERROR DEBUG] (expected)", levels)
+	}
+}
+
+type callerMapper struct {
+	recordMapper
+	caller *any
+}
+
+func (c *callerMapper) WithField(key string, value any) loggers.Contextual {
+	*c.caller = value
+	return NewContextualMap(c)
+}
+
+func TestCallerLogger(t *testing.T) {
+	var levels []Level
+	var caller any
+	l := NewCallerLogger(NewContextualMap(&callerMapper{recordMapper{levels: &levels}, &caller}))
+	l.Info("where")
+
+	if s, _ := caller.(string); !strings.HasPrefix(s, "mappers/mappers_test.go:") {
+		t.Errorf("Caller mismatch %v (actual) != mappers/mappers_test.go:<line> (expected)", caller)
+	}
+}
+
+func TestStackLogger(t *testing.T) {
+	var levels []Level
+	var stack any
+	l := NewStackLogger(NewContextualMap(&callerMapper{recordMapper{levels: &levels}, &stack}), LevelWarn)
+	l.Info("no stack")
+	if stack != nil {
+		t.Errorf("Stack mismatch %v (actual) != <nil> (expected)", stack)
+	}
+	l.Warn("stack")
+	s, _ := stack.(string)
+	if !strings.HasPrefix(s, "github.com/marcaudefroy/loggers/mappers.TestStackLogger\n\t") || !strings.Contains(s, "mappers_test.go:") {
+		t.Errorf("Stack mismatch %q (actual) != TestStackLogger frame first (expected)", s)
+	}
+	if len(levels) != 2 {
+		t.Errorf("Entries mismatch %d (actual) != 2 (expected)", len(levels))
+	}
+}
+
+func TestSamplingLogger(t *testing.T) {
+	var levels []Level
+	l := NewSamplingLogger(NewContextualMap(&recordMapper{levels: &levels}), time.Hour, 2, 3)
+	for i := 0; i < 8; i++ {
+		l.WithField("i", i).Infof("repeated %d", i)
+	}
+	// 2 initial entries, then the 5th and 8th ones.
+	if len(levels) != 4 {
+		t.Errorf("Sampled entries mismatch %d (actual) != 4 (expected)", len(levels))
+	}
+}
+
+type fieldsMapper struct {
+	recordMapper
+	messages *[]string
+}
+
+func (f *fieldsMapper) LevelPrint(lev Level, v ...any) {
+	*f.messages = append(*f.messages, fmt.Sprint(v...))
+}
+func (f *fieldsMapper) WithFields(fields ...any) loggers.Contextual {
+	*f.messages = append(*f.messages, fmt.Sprint(fields...))
+	return NewContextualMap(f)
+}
+
+func TestRedactingLogger(t *testing.T) {
+	var messages []string
+	l := NewRedactingLogger(NewContextualMap(&fieldsMapper{messages: &messages}), Redaction{
+		Keys:     []string{"Token"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`secret-\w+`)},
+	})
+	l.WithFields("token", "abc", "note", "secret-1").Infof("got %s", "secret-2")
+
+	expected := []string{"token[REDACTED]note[REDACTED]", "got [REDACTED]"}
+	if strings.Join(messages, "|") != strings.Join(expected, "|") {
+		t.Errorf("Redacted output mismatch %q (actual) != %q (expected)", messages, expected)
+	}
+}
@@ -0,0 +1,316 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// value is an OpenTelemetry AnyValue.
+type value struct {
+	kind   valueKind
+	str    string
+	num    int64
+	float  float64
+	bytes  []byte
+	values []value
+	kvs    []keyValue
+}
+
+type valueKind int
+
+const (
+	stringValue valueKind = iota
+	boolValue
+	intValue
+	doubleValue
+	arrayValue
+	kvlistValue
+	bytesValue
+)
+
+type keyValue struct {
+	key   string
+	value value
+}
+
+// toValue converts v to an AnyValue. Values without a counterpart are converted to their fmt.Sprint string.
+func toValue(v any) value {
+	switch v := v.(type) {
+	case string:
+		return value{kind: stringValue, str: v}
+	case bool:
+		if v {
+			return value{kind: boolValue, num: 1}
+		}
+		return value{kind: boolValue}
+	case int:
+		return value{kind: intValue, num: int64(v)}
+	case int8:
+		return value{kind: intValue, num: int64(v)}
+	case int16:
+		return value{kind: intValue, num: int64(v)}
+	case int32:
+		return value{kind: intValue, num: int64(v)}
+	case int64:
+		return value{kind: intValue, num: v}
+	case uint8:
+		return value{kind: intValue, num: int64(v)}
+	case uint16:
+		return value{kind: intValue, num: int64(v)}
+	case uint32:
+		return value{kind: intValue, num: int64(v)}
+	case float32:
+		return toValue(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// Not representable in OTLP/JSON.
+			return value{kind: stringValue, str: strconv.FormatFloat(v, 'g', -1, 64)}
+		}
+		return value{kind: doubleValue, float: v}
+	case []byte:
+		return value{kind: bytesValue, bytes: v}
+	case time.Duration:
+		return value{kind: stringValue, str: v.String()}
+	case error:
+		if isNilPointer(v) {
+			return value{kind: stringValue, str: "<nil>"}
+		}
+		return value{kind: stringValue, str: v.Error()}
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return value{kind: stringValue, str: "<nil>"}
+		}
+		return value{kind: stringValue, str: v.String()}
+	case map[string]any:
+		kv := value{kind: kvlistValue}
+		for k, e := range v {
+			kv.kvs = append(kv.kvs, keyValue{key: k, value: toValue(e)})
+		}
+		return kv
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		a := value{kind: arrayValue, values: make([]value, rv.Len())}
+		for i := range a.values {
+			a.values[i] = toValue(rv.Index(i).Interface())
+		}
+		return a
+	}
+	return value{kind: stringValue, str: fmt.Sprint(v)}
+}
+
+// isNilPointer reports whether v is a nil pointer, whose methods may panic.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// attributes converts key value pairs to attributes.
+func attributes(fields []any) []keyValue {
+	kvs := make([]keyValue, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		kvs = append(kvs, keyValue{key: fmt.Sprint(fields[i]), value: toValue(fields[i+1])})
+	}
+	return kvs
+}
+
+// request is an ExportLogsServiceRequest holding a single resource and instrumentation scope.
+type request struct {
+	resource []keyValue
+	scope    string
+	records  []Record
+}
+
+// Protobuf field numbers of the OTLP messages.
+const (
+	fieldResourceLogs = 1 // ExportLogsServiceRequest.resource_logs
+
+	fieldResource  = 1 // ResourceLogs.resource
+	fieldScopeLogs = 2 // ResourceLogs.scope_logs
+
+	fieldResourceAttributes = 1 // Resource.attributes
+
+	fieldScope      = 1 // ScopeLogs.scope
+	fieldLogRecords = 2 // ScopeLogs.log_records
+	fieldScopeName  = 1 // InstrumentationScope.name
+
+	fieldTime           = 1  // LogRecord.time_unix_nano
+	fieldSeverityNumber = 2  // LogRecord.severity_number
+	fieldSeverityText   = 3  // LogRecord.severity_text
+	fieldBody           = 5  // LogRecord.body
+	fieldAttributes     = 6  // LogRecord.attributes
+	fieldFlags          = 8  // LogRecord.flags
+	fieldTraceID        = 9  // LogRecord.trace_id
+	fieldSpanID         = 10 // LogRecord.span_id
+	fieldObservedTime   = 11 // LogRecord.observed_time_unix_nano
+
+	fieldKey   = 1 // KeyValue.key
+	fieldValue = 2 // KeyValue.value
+
+	fieldValues = 1 // ArrayValue.values and KeyValueList.values
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wire))
+}
+
+func appendBytesField(b []byte, field int, p []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(p)))
+	return append(b, p...)
+}
+
+func appendStringField(b []byte, field int, s string) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendFixed64Field(b []byte, field int, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(appendTag(b, field, wireFixed64), v)
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendTag(b, field, wireVarint), v)
+}
+
+// marshalProto encodes r as a protobuf ExportLogsServiceRequest.
+func (r *request) marshalProto() []byte {
+	var resource []byte
+	for _, kv := range r.resource {
+		resource = appendBytesField(resource, fieldResourceAttributes, kv.appendProto(nil))
+	}
+	scope := appendStringField(nil, fieldScopeName, r.scope)
+
+	scopeLogs := appendBytesField(nil, fieldScope, scope)
+	for _, rec := range r.records {
+		scopeLogs = appendBytesField(scopeLogs, fieldLogRecords, appendRecordProto(nil, rec))
+	}
+	resourceLogs := appendBytesField(nil, fieldResource, resource)
+	resourceLogs = appendBytesField(resourceLogs, fieldScopeLogs, scopeLogs)
+	return appendBytesField(nil, fieldResourceLogs, resourceLogs)
+}
+
+func appendRecordProto(b []byte, rec Record) []byte {
+	ts := uint64(rec.Time.UnixNano())
+	b = appendFixed64Field(b, fieldTime, ts)
+	b = appendVarintField(b, fieldSeverityNumber, uint64(rec.Severity))
+	b = appendStringField(b, fieldSeverityText, rec.SeverityText)
+	b = appendBytesField(b, fieldBody, toValue(rec.Body).appendProto(nil))
+	for _, kv := range attributes(rec.Attributes) {
+		b = appendBytesField(b, fieldAttributes, kv.appendProto(nil))
+	}
+	if rec.TraceID != [16]byte{} {
+		b = binary.LittleEndian.AppendUint32(appendTag(b, fieldFlags, wireFixed32), uint32(rec.TraceFlags))
+		b = appendBytesField(b, fieldTraceID, rec.TraceID[:])
+		b = appendBytesField(b, fieldSpanID, rec.SpanID[:])
+	}
+	return appendFixed64Field(b, fieldObservedTime, ts)
+}
+
+func (kv keyValue) appendProto(b []byte) []byte {
+	b = appendStringField(b, fieldKey, kv.key)
+	return appendBytesField(b, fieldValue, kv.value.appendProto(nil))
+}
+
+// appendProto appends v as an AnyValue, whose oneof field numbers follow the value kinds.
+func (v value) appendProto(b []byte) []byte {
+	field := int(v.kind) + 1
+	switch v.kind {
+	case stringValue:
+		return appendStringField(b, field, v.str)
+	case boolValue, intValue:
+		return appendVarintField(b, field, uint64(v.num))
+	case doubleValue:
+		return appendFixed64Field(b, field, math.Float64bits(v.float))
+	case bytesValue:
+		return appendBytesField(b, field, v.bytes)
+	case arrayValue:
+		var values []byte
+		for _, e := range v.values {
+			values = appendBytesField(values, fieldValues, e.appendProto(nil))
+		}
+		return appendBytesField(b, field, values)
+	case kvlistValue:
+		var values []byte
+		for _, kv := range v.kvs {
+			values = appendBytesField(values, fieldValues, kv.appendProto(nil))
+		}
+		return appendBytesField(b, field, values)
+	}
+	return b
+}
+
+// marshalJSON encodes r as an ExportLogsServiceRequest in the OTLP/JSON format,
+// where 64 bits integers are strings and field names are in lower camel case.
+func (r *request) marshalJSON() ([]byte, error) {
+	records := make([]map[string]any, len(r.records))
+	for i, rec := range r.records {
+		ts := strconv.FormatInt(rec.Time.UnixNano(), 10)
+		records[i] = map[string]any{
+			"timeUnixNano":         ts,
+			"observedTimeUnixNano": ts,
+			"severityNumber":       rec.Severity,
+			"severityText":         rec.SeverityText,
+			"body":                 toValue(rec.Body).jsonValue(),
+			"attributes":           jsonKeyValues(attributes(rec.Attributes)),
+		}
+		if rec.TraceID != [16]byte{} {
+			records[i]["traceId"] = hex.EncodeToString(rec.TraceID[:])
+			records[i]["spanId"] = hex.EncodeToString(rec.SpanID[:])
+			records[i]["flags"] = rec.TraceFlags
+		}
+	}
+	return json.Marshal(map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": jsonKeyValues(r.resource)},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]any{"name": r.scope},
+				"logRecords": records,
+			}},
+		}},
+	})
+}
+
+func jsonKeyValues(kvs []keyValue) []any {
+	values := make([]any, len(kvs))
+	for i, kv := range kvs {
+		values[i] = map[string]any{"key": kv.key, "value": kv.value.jsonValue()}
+	}
+	return values
+}
+
+func (v value) jsonValue() map[string]any {
+	switch v.kind {
+	case boolValue:
+		return map[string]any{"boolValue": v.num != 0}
+	case intValue:
+		return map[string]any{"intValue": strconv.FormatInt(v.num, 10)}
+	case doubleValue:
+		return map[string]any{"doubleValue": v.float}
+	case bytesValue:
+		return map[string]any{"bytesValue": v.bytes}
+	case arrayValue:
+		values := make([]any, len(v.values))
+		for i, e := range v.values {
+			values[i] = e.jsonValue()
+		}
+		return map[string]any{"arrayValue": map[string]any{"values": values}}
+	case kvlistValue:
+		return map[string]any{"kvlistValue": map[string]any{"values": jsonKeyValues(v.kvs)}}
+	}
+	return map[string]any{"stringValue": v.str}
+}
@@ -0,0 +1,311 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Encoding is the encoding of the OTLP/HTTP requests.
+type Encoding int
+
+const (
+	// Protobuf encodes requests as binary protobuf, the OTLP default.
+	Protobuf Encoding = iota
+	// JSON encodes requests in the OTLP/JSON format.
+	JSON
+)
+
+const (
+	// DefaultEndpoint is the logs endpoint of a local collector.
+	DefaultEndpoint = "http://localhost:4318/v1/logs"
+	// DefaultScope is the default instrumentation scope name.
+	DefaultScope = "github.com/marcaudefroy/loggers"
+	// DefaultFlushInterval is the default interval between exports of the queued records.
+	DefaultFlushInterval = time.Second
+	// DefaultBatchSize is the default number of queued records triggering an export.
+	DefaultBatchSize = 512
+	// DefaultQueueSize is the default maximum number of queued records.
+	DefaultQueueSize = 2048
+	// DefaultMaxRetries is the default number of retries of a failed export.
+	DefaultMaxRetries = 5
+	// DefaultInitialBackoff is the default delay before the first retry, doubled after each retry.
+	DefaultInitialBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the default maximum delay between retries.
+	DefaultMaxBackoff = 5 * time.Second
+	// DefaultTimeout is the default timeout of an export request.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Options configures an Exporter. The zero value is usable.
+type Options struct {
+	// Endpoint is the URL of the logs endpoint, DefaultEndpoint by default.
+	Endpoint string
+	// Encoding of the requests, Protobuf by default.
+	Encoding Encoding
+	// Headers are added to each request, e.g. for authentication.
+	Headers map[string]string
+	// Resource holds the resource attributes. service.name defaults to unknown_service:<executable>.
+	Resource map[string]any
+	// Scope is the instrumentation scope name, DefaultScope by default.
+	Scope string
+	// FlushInterval is the interval between exports of the queued records.
+	FlushInterval time.Duration
+	// BatchSize is the maximum number of records of a request, an export is triggered when reached.
+	BatchSize int
+	// QueueSize is the maximum number of queued records, the oldest records are dropped beyond it.
+	QueueSize int
+	// MaxRetries is the number of retries of a failed export, negative to disable retries.
+	MaxRetries int
+	// InitialBackoff and MaxBackoff bound the delay between retries.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout bounds each request.
+	Timeout time.Duration
+	// Client sends the requests, a client with Timeout by default.
+	Client *http.Client
+}
+
+// Exporter queues records and exports them in batches to an OTLP/HTTP endpoint.
+// Failed exports are retried with an exponential backoff on network errors and on the
+// 429, 502, 503 and 504 statuses, honoring Retry-After. It is safe for concurrent use.
+type Exporter struct {
+	opts     Options
+	resource []keyValue
+
+	mu      sync.Mutex
+	queue   []Record
+	dropped int
+
+	exportMu sync.Mutex
+
+	flush     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewExporter returns an Exporter exporting records with the given options.
+func NewExporter(opts Options) *Exporter {
+	if opts.Endpoint == "" {
+		opts.Endpoint = DefaultEndpoint
+	}
+	if opts.Scope == "" {
+		opts.Scope = DefaultScope
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	var resource []any
+	if _, ok := opts.Resource["service.name"]; !ok {
+		resource = append(resource, "service.name", "unknown_service:"+filepath.Base(os.Args[0]))
+	}
+	for k, v := range opts.Resource {
+		resource = append(resource, k, v)
+	}
+
+	e := Exporter{
+		opts:     opts,
+		resource: attributes(resource),
+		flush:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return &e
+}
+
+// Export queues r, to be exported with the next batch.
+func (e *Exporter) Export(r Record) {
+	e.mu.Lock()
+	e.queue = append(e.queue, r)
+	if n := len(e.queue) - e.opts.QueueSize; n > 0 {
+		e.queue = append(e.queue[:0], e.queue[n:]...)
+		e.dropped += n
+	}
+	full := len(e.queue) >= e.opts.BatchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Dropped returns the number of records dropped because the queue was full or their export failed.
+func (e *Exporter) Dropped() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// Flush exports the queued records. Records whose export failed after all retries are dropped.
+func (e *Exporter) Flush() error {
+	e.exportMu.Lock()
+	defer e.exportMu.Unlock()
+
+	var errs []error
+	for {
+		e.mu.Lock()
+		n := min(len(e.queue), e.opts.BatchSize)
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+		if n == 0 {
+			return errors.Join(errs...)
+		}
+		if err := e.export(batch); err != nil {
+			e.mu.Lock()
+			e.dropped += len(batch)
+			e.mu.Unlock()
+			errs = append(errs, err)
+		}
+	}
+}
+
+// flushWithin exports the queued records, waiting at most timeout for the export to complete.
+func (e *Exporter) flushWithin(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		if err := e.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "otlp: %v\n", err)
+		}
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+// Close exports the queued records and stops the exporter.
+// Calling it again has no effect.
+func (e *Exporter) Close() error {
+	var err error
+	e.closeOnce.Do(func() {
+		close(e.done)
+		e.wg.Wait()
+		err = e.Flush()
+	})
+	return err
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		case <-e.flush:
+		}
+		if err := e.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "otlp: %v\n", err)
+		}
+	}
+}
+
+// export sends records, retrying with an exponential backoff.
+func (e *Exporter) export(records []Record) error {
+	r := request{resource: e.resource, scope: e.opts.Scope, records: records}
+	var body []byte
+	contentType := "application/x-protobuf"
+	if e.opts.Encoding == JSON {
+		var err error
+		if body, err = r.marshalJSON(); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	} else {
+		body = r.marshalProto()
+	}
+
+	backoff := e.opts.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := e.post(body, contentType)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= e.opts.MaxRetries {
+			return fmt.Errorf("exporting %d records: %w", len(records), err)
+		}
+		delay := max(backoff, retryAfter)
+		select {
+		case <-time.After(delay):
+		case <-e.done:
+			// Closing, make one last attempt without waiting.
+			if _, err := e.post(body, contentType); err != nil {
+				return fmt.Errorf("exporting %d records: %w", len(records), err)
+			}
+			return nil
+		}
+		backoff = min(2*backoff, e.opts.MaxBackoff)
+	}
+}
+
+// post sends a request. On failure, it returns the delay requested by the server before
+// retrying, or a negative delay when the failure is not retryable.
+func (e *Exporter) post(body []byte, contentType string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return 0, nil
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		var retryAfter time.Duration
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = min(time.Duration(s)*time.Second, e.opts.MaxBackoff)
+		}
+		return retryAfter, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return -1, fmt.Errorf("unexpected status %s", resp.Status)
+}
@@ -0,0 +1,139 @@
+// Package otlp maps a Contextual logger to the OpenTelemetry log data model and exports
+// the entries to an OpenTelemetry collector with the OTLP/HTTP protocol.
+package otlp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Record is an entry in the OpenTelemetry log data model.
+type Record struct {
+	Time         time.Time
+	Severity     int
+	SeverityText string
+	Body         any
+	// Attributes are the key value pairs of the contextual fields, in order.
+	Attributes []any
+	// TraceID, SpanID and TraceFlags correlate the record with a trace, when TraceID is not zero.
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+}
+
+// Severity returns the OpenTelemetry severity number of a level,
+// the first number of the matching severity range, Panic being the second FATAL number.
+func Severity(lev mappers.Level) int {
+	switch lev {
+	case mappers.LevelDebug:
+		return 5
+	case mappers.LevelInfo:
+		return 9
+	case mappers.LevelWarn:
+		return 13
+	case mappers.LevelError:
+		return 17
+	case mappers.LevelFatal:
+		return 21
+	case mappers.LevelPanic:
+		return 22
+	}
+	return 0
+}
+
+// otlpLogger maps an Exporter to a Contextual logger interface.
+type otlpLogger struct {
+	exporter *Exporter
+	fields   []any
+	// traced is set when fields hold trace correlation fields.
+	traced bool
+}
+
+// NewLogger returns a Contextual logger exporting its entries with e.
+// The message is the record body and the fields its attributes, except the
+// trace correlation fields of the ctxlog package which set the record trace context.
+// As the program ends right after Fatal and Panic entries, they are exported before returning,
+// waiting at most the Timeout of the exporter.
+func NewLogger(e *Exporter) loggers.Contextual {
+	return mappers.NewContextualMap(&otlpLogger{exporter: e})
+}
+
+func (l *otlpLogger) GetUnderlying() any {
+	return l.exporter
+}
+
+// LevelPrint is a Mapper method
+func (l *otlpLogger) LevelPrint(lev mappers.Level, i ...any) {
+	text, _ := lev.MarshalText()
+	r := Record{
+		Time:         time.Now(),
+		Severity:     Severity(lev),
+		SeverityText: strings.ToUpper(string(text)),
+		Body:         fmt.Sprint(i...),
+		Attributes:   l.fields,
+	}
+	if l.traced {
+		r.Attributes = r.Attributes[:0:0]
+		for i := 0; i+1 < len(l.fields); i = i + 2 {
+			if !r.setTraceField(l.fields[i], l.fields[i+1]) {
+				r.Attributes = append(r.Attributes, l.fields[i], l.fields[i+1])
+			}
+		}
+	}
+	l.exporter.Export(r)
+	if lev >= mappers.LevelFatal {
+		l.exporter.flushWithin(l.exporter.opts.Timeout)
+	}
+}
+
+// setTraceField sets the trace correlation field key of r, as added by ctxlog, if value is valid hex.
+func (r *Record) setTraceField(key, value any) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	var err error
+	switch key {
+	case ctxlog.TraceIDKey:
+		_, err = hex.Decode(r.TraceID[:], []byte(s))
+	case ctxlog.SpanIDKey:
+		_, err = hex.Decode(r.SpanID[:], []byte(s))
+	case ctxlog.TraceFlagsKey:
+		var flags [1]byte
+		_, err = hex.Decode(flags[:], []byte(s))
+		r.TraceFlags = flags[0]
+	default:
+		return false
+	}
+	return err == nil
+}
+
+// LevelPrintf is a Mapper method
+func (l *otlpLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	l.LevelPrint(lev, fmt.Sprintf(format, i...))
+}
+
+// LevelPrintln is a Mapper method
+func (l *otlpLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	l.LevelPrint(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"))
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *otlpLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *otlpLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := otlpLogger{exporter: l.exporter, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...), traced: l.traced}
+	for i := 0; i < len(fields); i = i + 2 {
+		nl.traced = nl.traced || fields[i] == ctxlog.TraceIDKey
+	}
+	return mappers.NewContextualMap(&nl)
+}
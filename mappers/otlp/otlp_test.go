@@ -0,0 +1,291 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+func TestOTLPInterface(t *testing.T) {
+	e := NewExporter(Options{})
+	defer e.Close()
+	var _ loggers.Contextual = NewLogger(e)
+}
+
+// collector is an httptest stand-in for an OTLP/HTTP collector, answering with statuses in turn.
+type collector struct {
+	*httptest.Server
+	statuses []int
+	requests atomic.Int32
+	bodies   chan []byte
+	header   chan http.Header
+}
+
+func newCollector(t *testing.T, statuses ...int) *collector {
+	c := collector{statuses: statuses, bodies: make(chan []byte, 10), header: make(chan http.Header, 10)}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(c.requests.Add(1)) - 1
+		if r.URL.Path != "/v1/logs" || r.Method != http.MethodPost {
+			t.Errorf("Request mismatch %s %s", r.Method, r.URL.Path)
+		}
+		if n < len(c.statuses) && c.statuses[n] != http.StatusOK {
+			w.WriteHeader(c.statuses[n])
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		c.bodies <- b
+		c.header <- r.Header
+	}))
+	t.Cleanup(c.Close)
+	return &c
+}
+
+func (c *collector) receive(t *testing.T) ([]byte, http.Header) {
+	t.Helper()
+	select {
+	case b := <-c.bodies:
+		return b, <-c.header
+	case <-time.After(2 * time.Second):
+		t.Fatalf("No request received")
+		return nil, nil
+	}
+}
+
+func TestOTLPNilPointers(t *testing.T) {
+	var err *url.Error
+	var u *url.URL
+	for _, v := range []any{err, u} {
+		if a := toValue(v); a.kind != stringValue || a.str != "<nil>" {
+			t.Errorf("Value of %T mismatch %q (actual) != <nil> (expected)", v, a.str)
+		}
+	}
+}
+
+func TestOTLPCloseTwice(t *testing.T) {
+	e := NewExporter(Options{})
+	e.Close()
+	if err := e.Close(); err != nil {
+		t.Errorf("Second Close failed: %v", err)
+	}
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	expected := map[mappers.Level]int{mappers.LevelDebug: 5, mappers.LevelInfo: 9, mappers.LevelWarn: 13,
+		mappers.LevelError: 17, mappers.LevelFatal: 21, mappers.LevelPanic: 22}
+	for lev, n := range expected {
+		if s := Severity(lev); s != n {
+			t.Errorf("Severity of %s mismatch %d (actual) != %d (expected)", lev, s, n)
+		}
+	}
+}
+
+func TestOTLPJSON(t *testing.T) {
+	c := newCollector(t)
+	e := NewExporter(Options{
+		Endpoint:      c.URL + "/v1/logs",
+		Encoding:      JSON,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		Resource:      map[string]any{"service.name": "api"},
+		FlushInterval: time.Hour,
+	})
+	defer e.Close()
+
+	NewLogger(e).WithFields("user", "bob", "n", 7, "ok", true, "tags", []string{"a"}).Warnf("disk %d%% full", 90)
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	b, h := c.receive(t)
+	if ct := h.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type mismatch %q (actual) != %q (expected)", ct, "application/json")
+	}
+	if a := h.Get("Authorization"); a != "Bearer token" {
+		t.Errorf("Authorization mismatch %q (actual) != %q (expected)", a, "Bearer token")
+	}
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource  struct{ Attributes []json.RawMessage }
+			ScopeLogs []struct {
+				Scope      struct{ Name string }
+				LogRecords []struct {
+					TimeUnixNano   string
+					SeverityNumber int
+					SeverityText   string
+					Body           map[string]any
+					Attributes     []struct {
+						Key   string
+						Value map[string]any
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatalf("Unmarshal of %s failed: %v", b, err)
+	}
+	rl := req.ResourceLogs[0]
+	if resource := string(rl.Resource.Attributes[0]); resource != `{"key":"service.name","value":{"stringValue":"api"}}` {
+		t.Errorf("Resource mismatch %s", resource)
+	}
+	if rl.ScopeLogs[0].Scope.Name != DefaultScope {
+		t.Errorf("Scope mismatch %q (actual) != %q (expected)", rl.ScopeLogs[0].Scope.Name, DefaultScope)
+	}
+	rec := rl.ScopeLogs[0].LogRecords[0]
+	if rec.SeverityNumber != 13 || rec.SeverityText != "WARN" || rec.Body["stringValue"] != "disk 90% full" {
+		t.Errorf("Record mismatch %+v", rec)
+	}
+	if ns, _ := time.ParseDuration(rec.TimeUnixNano + "ns"); time.Since(time.Unix(0, int64(ns))) > time.Minute {
+		t.Errorf("Time mismatch %s", rec.TimeUnixNano)
+	}
+	attrs, _ := json.Marshal(rec.Attributes)
+	expected := `[{"Key":"user","Value":{"stringValue":"bob"}},{"Key":"n","Value":{"intValue":"7"}},` +
+		`{"Key":"ok","Value":{"boolValue":true}},{"Key":"tags","Value":{"arrayValue":{"values":[{"stringValue":"a"}]}}}]`
+	if string(attrs) != expected {
+		t.Errorf("Attributes mismatch %s (actual) != %s (expected)", attrs, expected)
+	}
+}
+
+// protoFields decodes the fields of a protobuf message, varint and fixed64 values as uint64,
+// length delimited values as []byte.
+func protoFields(t *testing.T, b []byte) map[int][]any {
+	t.Helper()
+	fields := make(map[int][]any)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		b = b[n:]
+		var v any
+		switch tag & 7 {
+		case wireVarint:
+			v, n = binary.Uvarint(b)
+		case wireFixed64:
+			v, n = binary.LittleEndian.Uint64(b), 8
+		case wireBytes:
+			l, m := binary.Uvarint(b)
+			v, n = b[m:m+int(l)], m+int(l)
+		default:
+			t.Fatalf("Unexpected wire type %d", tag&7)
+		}
+		fields[int(tag>>3)] = append(fields[int(tag>>3)], v)
+		b = b[n:]
+	}
+	return fields
+}
+
+func TestOTLPProtobuf(t *testing.T) {
+	c := newCollector(t)
+	e := NewExporter(Options{Endpoint: c.URL + "/v1/logs", FlushInterval: time.Hour, BatchSize: 2})
+	defer e.Close()
+
+	l := NewLogger(e)
+	l.WithField("n", -1).Error("first")
+	l.Debug("second")
+
+	b, h := c.receive(t)
+	if ct := h.Get("Content-Type"); ct != "application/x-protobuf" {
+		t.Errorf("Content-Type mismatch %q (actual) != %q (expected)", ct, "application/x-protobuf")
+	}
+	resourceLogs := protoFields(t, protoFields(t, b)[fieldResourceLogs][0].([]byte))
+	resource := protoFields(t, resourceLogs[fieldResource][0].([]byte))
+	kv := protoFields(t, resource[fieldResourceAttributes][0].([]byte))
+	if string(kv[fieldKey][0].([]byte)) != "service.name" {
+		t.Errorf("Resource attribute mismatch %q", kv[fieldKey][0])
+	}
+	records := protoFields(t, resourceLogs[fieldScopeLogs][0].([]byte))[fieldLogRecords]
+	if len(records) != 2 {
+		t.Fatalf("Records mismatch %d (actual) != 2 (expected)", len(records))
+	}
+	rec := protoFields(t, records[0].([]byte))
+	if rec[fieldSeverityNumber][0] != uint64(17) || string(rec[fieldSeverityText][0].([]byte)) != "ERROR" {
+		t.Errorf("Severity mismatch %v %q", rec[fieldSeverityNumber][0], rec[fieldSeverityText][0])
+	}
+	if body := protoFields(t, rec[fieldBody][0].([]byte)); string(body[1][0].([]byte)) != "first" {
+		t.Errorf("Body mismatch %q (actual) != %q (expected)", body[1][0], "first")
+	}
+	attr := protoFields(t, rec[fieldAttributes][0].([]byte))
+	value := protoFields(t, attr[fieldValue][0].([]byte))
+	if string(attr[fieldKey][0].([]byte)) != "n" || int64(value[3][0].(uint64)) != -1 {
+		t.Errorf("Attribute mismatch %q %v", attr[fieldKey][0], value)
+	}
+}
+
+func TestOTLPRetry(t *testing.T) {
+	c := newCollector(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	e := NewExporter(Options{Endpoint: c.URL + "/v1/logs", FlushInterval: time.Hour, InitialBackoff: time.Millisecond})
+	defer e.Close()
+
+	NewLogger(e).Info("retried")
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	c.receive(t)
+	if n := c.requests.Load(); n != 3 {
+		t.Errorf("Requests mismatch %d (actual) != 3 (expected)", n)
+	}
+}
+
+func TestOTLPNotRetryable(t *testing.T) {
+	c := newCollector(t, http.StatusBadRequest)
+	e := NewExporter(Options{Endpoint: c.URL + "/v1/logs", FlushInterval: time.Hour, InitialBackoff: time.Millisecond})
+	defer e.Close()
+
+	NewLogger(e).Info("rejected")
+	if err := e.Flush(); err == nil {
+		t.Errorf("Expected an error on a bad request")
+	}
+	if n := c.requests.Load(); n != 1 {
+		t.Errorf("Requests mismatch %d (actual) != 1 (expected)", n)
+	}
+	if n := e.Dropped(); n != 1 {
+		t.Errorf("Dropped mismatch %d (actual) != 1 (expected)", n)
+	}
+}
+
+func TestOTLPTraceContext(t *testing.T) {
+	c := newCollector(t)
+	e := NewExporter(Options{Endpoint: c.URL + "/v1/logs", Encoding: JSON, FlushInterval: time.Hour})
+	defer e.Close()
+
+	s, _ := ctxlog.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	NewLogger(e).WithFields(s.Fields()...).WithField("n", 1).Info("traced")
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	b, _ := c.receive(t)
+	for _, expected := range []string{`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`, `"spanId":"00f067aa0ba902b7"`,
+		`"flags":1`, `"attributes":[{"key":"n","value":{"intValue":"1"}}]`} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("Request mismatch %s (actual) does not contain %s (expected)", b, expected)
+		}
+	}
+}
+
+func TestOTLPPanicFlush(t *testing.T) {
+	c := newCollector(t)
+	e := NewExporter(Options{Endpoint: c.URL + "/v1/logs", Encoding: JSON, FlushInterval: time.Hour})
+	defer e.Close()
+
+	func() {
+		defer func() { recover() }()
+		NewLogger(e).Panic("crash")
+	}()
+	// No Flush: the record must have been exported before Panic returned.
+	select {
+	case b := <-c.bodies:
+		if !strings.Contains(string(b), `"crash"`) {
+			t.Errorf("Body %s must hold the panic record", b)
+		}
+	default:
+		t.Errorf("The panic record was not exported before Panic returned")
+	}
+}
@@ -0,0 +1,154 @@
+// Package preset describes the production and development setups shared by the
+// NewProduction and NewDevelopment constructors of the stdlib, slog and logrus mappers.
+package preset
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+)
+
+// Defaults of the production sampling: per second, the first 100 entries with the same level
+// and message are logged, then one out of 100.
+const (
+	DefaultSamplingTick       = time.Second
+	DefaultSamplingInitial    = 100
+	DefaultSamplingThereafter = 100
+)
+
+// Config is a preset after its options were applied. Backends build their logger
+// writing to Output, then call Wrap.
+type Config struct {
+	// Output is the destination of the entries, stderr by default.
+	Output io.Writer
+	// Level is the minimum level, Info in production and Debug in development.
+	Level mappers.Level
+	// Sampling limits repeated entries when set, as NewSamplingLogger does.
+	Sampling           bool
+	SamplingTick       time.Duration
+	SamplingInitial    int
+	SamplingThereafter int
+	// Caller adds the location of the logging call as the caller field.
+	Caller bool
+	// Stack adds the calling stack to the entries at or above StackLevel as the stack field.
+	Stack      bool
+	StackLevel mappers.Level
+	// Color tells when the development output is colorized, ColorAuto by default.
+	// Production outputs are never colorized.
+	Color console.ColorMode
+	// Fields are added to every entry.
+	Fields []any
+}
+
+// Option tweaks a preset.
+type Option func(*Config)
+
+// Output sets the destination of the entries.
+func Output(w io.Writer) Option {
+	return func(c *Config) { c.Output = w }
+}
+
+// Level sets the minimum level.
+func Level(l mappers.Level) Option {
+	return func(c *Config) { c.Level = l }
+}
+
+// Sampling enables sampling with the given settings, see mappers.NewSamplingLogger.
+func Sampling(tick time.Duration, initial, thereafter int) Option {
+	return func(c *Config) {
+		c.Sampling = true
+		c.SamplingTick, c.SamplingInitial, c.SamplingThereafter = tick, initial, thereafter
+	}
+}
+
+// NoSampling disables sampling.
+func NoSampling() Option {
+	return func(c *Config) { c.Sampling = false }
+}
+
+// Caller enables or disables the caller field.
+func Caller(enabled bool) Option {
+	return func(c *Config) { c.Caller = enabled }
+}
+
+// StackFrom adds the calling stack to the entries at or above l.
+func StackFrom(l mappers.Level) Option {
+	return func(c *Config) { c.Stack, c.StackLevel = true, l }
+}
+
+// NoStack disables the stack field.
+func NoStack() Option {
+	return func(c *Config) { c.Stack = false }
+}
+
+// Color sets when the development output is colorized.
+func Color(mode console.ColorMode) Option {
+	return func(c *Config) { c.Color = mode }
+}
+
+// Fields adds fields to every entry.
+func Fields(fields ...any) Option {
+	return func(c *Config) { c.Fields = append(c.Fields, fields...) }
+}
+
+// Production returns the production preset: Info level, sampling and caller, to stderr.
+func Production(opts ...Option) Config {
+	c := Config{
+		Output:             os.Stderr,
+		Level:              mappers.LevelInfo,
+		Sampling:           true,
+		SamplingTick:       DefaultSamplingTick,
+		SamplingInitial:    DefaultSamplingInitial,
+		SamplingThereafter: DefaultSamplingThereafter,
+		Caller:             true,
+	}
+	return c.apply(opts)
+}
+
+// Development returns the development preset: Debug level, caller and a stack on Warn
+// and above, colored when stderr is a terminal.
+func Development(opts ...Option) Config {
+	c := Config{
+		Output:     os.Stderr,
+		Level:      mappers.LevelDebug,
+		Caller:     true,
+		Stack:      true,
+		StackLevel: mappers.LevelWarn,
+	}
+	return c.apply(opts)
+}
+
+func (c Config) apply(opts []Option) Config {
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// UseColor reports whether the output is colorized, following the rules of the console mapper.
+func (c Config) UseColor() bool {
+	return console.UseColor(c.Output, c.Color)
+}
+
+// Wrap adds the caller, stack, fields and sampling of the preset to l, a backend letting every
+// level through, and filters the entries below Level. The level can be changed with SetLevel.
+// Sampling comes first, so that the caller and stack are only computed for the entries kept.
+func (c Config) Wrap(l loggers.Contextual) *mappers.LevelFilter {
+	if c.Caller {
+		l = mappers.NewCallerLogger(l)
+	}
+	if c.Stack {
+		l = mappers.NewStackLogger(l, c.StackLevel)
+	}
+	if len(c.Fields) > 0 {
+		l = l.WithFields(c.Fields...)
+	}
+	if c.Sampling {
+		l = mappers.NewSamplingLogger(l, c.SamplingTick, c.SamplingInitial, c.SamplingThereafter)
+	}
+	return mappers.NewLevelFilter(l, mappers.NewLevelVar(c.Level))
+}
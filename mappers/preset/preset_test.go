@@ -0,0 +1,37 @@
+package preset_test
+
+import (
+	"bytes"
+	stdlog "log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/preset"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+func TestWrap(t *testing.T) {
+	var b bytes.Buffer
+	c := preset.Production(preset.Output(&b), preset.Sampling(time.Hour, 1, 0), preset.Fields("app", "demo"))
+	l := c.Wrap(stdlib.NewLogger(stdlog.New(c.Output, "", 0)))
+
+	l.Debug("filtered")
+	for i := 0; i < 3; i++ {
+		l.Info("repeated")
+	}
+	l.SetLevel(mappers.LevelDebug)
+	l.Debug("shown")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Lines mismatch %q (actual) != repeated and shown entries (expected)", b.String())
+	}
+	if !strings.HasPrefix(lines[0], "INFO  repeated [app=demo, caller=preset/preset_test.go:") {
+		t.Errorf("Line mismatch %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "DEBUG shown ") {
+		t.Errorf("Line mismatch %q", lines[1])
+	}
+}
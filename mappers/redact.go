@@ -0,0 +1,96 @@
+package mappers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+// RedactedValue is the default replacement of redacted values.
+const RedactedValue = "[REDACTED]"
+
+// Redaction describes the values hidden by NewRedactingLogger.
+type Redaction struct {
+	// Keys are field keys, compared ignoring case, whose values are replaced.
+	Keys []string
+	// Patterns are replaced in string field values and in messages.
+	Patterns []*regexp.Regexp
+	// Replacement defaults to RedactedValue.
+	Replacement string
+}
+
+// NewRedactingLogger returns a Contextual logger hiding sensitive values according to r.
+// When r has patterns, messages are formatted before being passed to l.
+func NewRedactingLogger(l loggers.Contextual, r Redaction) loggers.Contextual {
+	if r.Replacement == "" {
+		r.Replacement = RedactedValue
+	}
+	return NewContextualMap(&redactingLogger{logger: l, redaction: &r})
+}
+
+type redactingLogger struct {
+	logger    loggers.Contextual
+	redaction *Redaction
+}
+
+func (r *redactingLogger) GetUnderlying() any {
+	return r.logger.GetUnderlying()
+}
+
+func (r *redactingLogger) LevelPrint(lev Level, v ...any) {
+	if len(r.redaction.Patterns) == 0 {
+		Dispatch(r.logger, lev, v...)
+		return
+	}
+	Dispatch(r.logger, lev, r.redaction.redactString(fmt.Sprint(v...)))
+}
+
+func (r *redactingLogger) LevelPrintf(lev Level, format string, v ...any) {
+	if len(r.redaction.Patterns) == 0 {
+		Dispatchf(r.logger, lev, format, v...)
+		return
+	}
+	Dispatch(r.logger, lev, r.redaction.redactString(fmt.Sprintf(format, v...)))
+}
+
+func (r *redactingLogger) LevelPrintln(lev Level, v ...any) {
+	if len(r.redaction.Patterns) == 0 {
+		Dispatchln(r.logger, lev, v...)
+		return
+	}
+	Dispatch(r.logger, lev, r.redaction.redactString(strings.TrimSuffix(fmt.Sprintln(v...), "\n")))
+}
+
+func (r *redactingLogger) WithField(key string, value any) loggers.Contextual {
+	return r.WithFields(key, value)
+}
+
+func (r *redactingLogger) WithFields(fields ...any) loggers.Contextual {
+	redacted := make([]any, len(fields))
+	copy(redacted, fields)
+	for i := 0; i+1 < len(redacted); i = i + 2 {
+		redacted[i+1] = r.redaction.redactField(fmt.Sprint(redacted[i]), redacted[i+1])
+	}
+	return NewContextualMap(&redactingLogger{logger: r.logger.WithFields(redacted...), redaction: r.redaction})
+}
+
+func (r *Redaction) redactField(key string, value any) any {
+	for _, k := range r.Keys {
+		if strings.EqualFold(k, key) {
+			return r.Replacement
+		}
+	}
+	if s, ok := value.(string); ok {
+		return r.redactString(s)
+	}
+	return value
+}
+
+func (r *Redaction) redactString(s string) string {
+	for _, p := range r.Patterns {
+		s = p.ReplaceAllString(s, r.Replacement)
+	}
+	return s
+}
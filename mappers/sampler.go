@@ -0,0 +1,94 @@
+package mappers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+// NewSamplingLogger returns a Contextual logger limiting repeated entries.
+// During each tick, the first initial entries with the same level and message are logged,
+// then only one out of thereafter, none if thereafter is 0.
+// Fatal and Panic entries are never dropped. Derived loggers share the same counters.
+func NewSamplingLogger(l loggers.Contextual, tick time.Duration, initial, thereafter int) loggers.Contextual {
+	s := sampler{
+		tick:       tick,
+		initial:    initial,
+		thereafter: thereafter,
+		counts:     make(map[sampleKey]int),
+	}
+	return NewContextualMap(&samplingLogger{logger: l, sampler: &s})
+}
+
+type sampleKey struct {
+	level   Level
+	message string
+}
+
+type sampler struct {
+	tick                time.Duration
+	initial, thereafter int
+
+	mu     sync.Mutex
+	reset  time.Time
+	counts map[sampleKey]int
+}
+
+func (s *sampler) sample(lev Level, message string) bool {
+	if lev >= LevelFatal {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.After(s.reset) {
+		clear(s.counts)
+		s.reset = now.Add(s.tick)
+	}
+	k := sampleKey{lev, message}
+	s.counts[k]++
+	n := s.counts[k]
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+type samplingLogger struct {
+	logger  loggers.Contextual
+	sampler *sampler
+}
+
+func (s *samplingLogger) GetUnderlying() any {
+	return s.logger.GetUnderlying()
+}
+
+func (s *samplingLogger) LevelPrint(lev Level, v ...any) {
+	if s.sampler.sample(lev, fmt.Sprint(v...)) {
+		Dispatch(s.logger, lev, v...)
+	}
+}
+
+// LevelPrintf samples on the format rather than the formatted message,
+// so that entries differing only by their arguments are counted together.
+func (s *samplingLogger) LevelPrintf(lev Level, format string, v ...any) {
+	if s.sampler.sample(lev, format) {
+		Dispatchf(s.logger, lev, format, v...)
+	}
+}
+
+func (s *samplingLogger) LevelPrintln(lev Level, v ...any) {
+	if s.sampler.sample(lev, fmt.Sprint(v...)) {
+		Dispatchln(s.logger, lev, v...)
+	}
+}
+
+func (s *samplingLogger) WithField(key string, value any) loggers.Contextual {
+	return s.WithFields(key, value)
+}
+
+func (s *samplingLogger) WithFields(fields ...any) loggers.Contextual {
+	return NewContextualMap(&samplingLogger{logger: s.logger.WithFields(fields...), sampler: s.sampler})
+}
@@ -0,0 +1,78 @@
+package slog
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+	"github.com/marcaudefroy/loggers/mappers/preset"
+)
+
+// NewProduction returns a logger set up for production, see preset.Production.
+// Entries are written with a slog.JSONHandler.
+func NewProduction(opts ...preset.Option) *mappers.LevelFilter {
+	c := preset.Production(opts...)
+	handler := slog.NewJSONHandler(c.Output, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return c.Wrap(NewLogger(slog.New(handler)))
+}
+
+// NewDevelopment returns a logger set up for development, see preset.Development.
+// Entries are written with a slog.TextHandler using a short local time, the level colored on terminals.
+func NewDevelopment(opts ...preset.Option) *mappers.LevelFilter {
+	c := preset.Development(opts...)
+	w := c.Output
+	if c.UseColor() {
+		w = &colorWriter{w: w}
+	}
+	handler := slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.String(slog.TimeKey, a.Value.Time().Format(console.DefaultTimeLayout))
+			}
+			return a
+		},
+	})
+	return c.Wrap(NewLogger(slog.New(handler)))
+}
+
+// levels are the names of the levels written by a slog.TextHandler.
+var levels = map[string]mappers.Level{
+	"DEBUG": mappers.LevelDebug,
+	"INFO":  mappers.LevelInfo,
+	"WARN":  mappers.LevelWarn,
+	"ERROR": mappers.LevelError,
+}
+
+// colorWriter colors the level of the entries written by a slog.TextHandler,
+// which writes each entry with a single call, the level following the time.
+type colorWriter struct {
+	w io.Writer
+}
+
+func (c *colorWriter) Write(p []byte) (int, error) {
+	start := bytes.Index(p, []byte("level="))
+	if start < 0 {
+		return c.w.Write(p)
+	}
+	start += len("level=")
+	n := bytes.IndexAny(p[start:], " \n")
+	if n < 0 {
+		return c.w.Write(p)
+	}
+	end := start + n
+	lev, ok := levels[string(p[start:end])]
+	if !ok {
+		return c.w.Write(p)
+	}
+	colored := make([]byte, 0, len(p)+16)
+	colored = append(colored, p[:start]...)
+	colored = append(colored, console.Paint(lev, string(p[start:end]))...)
+	colored = append(colored, p[end:]...)
+	if _, err := c.w.Write(colored); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
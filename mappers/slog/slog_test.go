@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"log/slog"
+	"strings"
 	"testing"
+
+	"github.com/marcaudefroy/loggers/mappers/console"
+	"github.com/marcaudefroy/loggers/mappers/preset"
 )
 
 func TestSlogAdapter(t *testing.T) {
@@ -56,3 +60,35 @@ func TestSlogAdapter(t *testing.T) {
 		}
 	}
 }
+
+func TestSlogPresets(t *testing.T) {
+	// Le preset de production écrit du JSON au niveau Info, avec le caller
+	var buf bytes.Buffer
+	logger := NewProduction(preset.Output(&buf))
+	logger.Debug("ignoré")
+	logger.Info("démarré")
+
+	var logEntry map[string]any
+	if err := json.NewDecoder(&buf).Decode(&logEntry); err != nil {
+		t.Fatalf("Échec du décodage de la sortie JSON : %v", err)
+	}
+	if msg := logEntry["msg"]; msg != "démarré" {
+		t.Errorf("Message incorrect : attendu 'démarré', obtenu '%v'", msg)
+	}
+	if caller, _ := logEntry["caller"].(string); !strings.HasPrefix(caller, "slog/slog_test.go:") {
+		t.Errorf("Caller incorrect : attendu 'slog/slog_test.go:<ligne>', obtenu '%v'", caller)
+	}
+
+	// Le preset de développement écrit du texte coloré au niveau Debug, avec la pile sur Warn
+	buf.Reset()
+	logger = NewDevelopment(preset.Output(&buf), preset.Color(console.ColorAlways))
+	logger.Debug("visible")
+	logger.Warn("attention")
+	out := buf.String()
+	if !strings.Contains(out, "level=\x1b[35mDEBUG\x1b[0m msg=visible") {
+		t.Errorf("Sortie incorrecte pour Debug : %q", out)
+	}
+	if !strings.Contains(out, "level=\x1b[33mWARN\x1b[0m msg=attention") || !strings.Contains(out, "stack=\"github.com/marcaudefroy/loggers/mappers/slog.TestSlogPresets\\n\\t") {
+		t.Errorf("Sortie incorrecte pour Warn : %q", out)
+	}
+}
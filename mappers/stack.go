@@ -0,0 +1,79 @@
+package mappers
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+// StackKey is the field key used by NewStackLogger.
+const StackKey = "stack"
+
+// Stack returns the calling stack, starting at the first function outside of this module,
+// formatted as in a panic: the function on a line and its file and line on the next indented one.
+func Stack() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	caller := false
+	for {
+		f, more := frames.Next()
+		if caller || !isModuleFrame(f) {
+			caller = true
+			b.WriteString(f.Function)
+			b.WriteString("\n\t")
+			b.WriteString(f.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(f.Line))
+			b.WriteByte('\n')
+		}
+		if !more {
+			return strings.TrimSuffix(b.String(), "\n")
+		}
+	}
+}
+
+// NewStackLogger returns a Contextual logger adding the calling stack to the entries
+// at or above min as the StackKey field.
+func NewStackLogger(l loggers.Contextual, min Level) loggers.Contextual {
+	return NewContextualMap(&stackLogger{logger: l, min: min})
+}
+
+type stackLogger struct {
+	logger loggers.Contextual
+	min    Level
+}
+
+func (s *stackLogger) GetUnderlying() any {
+	return s.logger.GetUnderlying()
+}
+
+func (s *stackLogger) withStack(lev Level) loggers.Contextual {
+	if lev < s.min {
+		return s.logger
+	}
+	return s.logger.WithField(StackKey, Stack())
+}
+
+func (s *stackLogger) LevelPrint(lev Level, v ...any) {
+	Dispatch(s.withStack(lev), lev, v...)
+}
+
+func (s *stackLogger) LevelPrintf(lev Level, format string, v ...any) {
+	Dispatchf(s.withStack(lev), lev, format, v...)
+}
+
+func (s *stackLogger) LevelPrintln(lev Level, v ...any) {
+	Dispatchln(s.withStack(lev), lev, v...)
+}
+
+func (s *stackLogger) WithField(key string, value any) loggers.Contextual {
+	return NewStackLogger(s.logger.WithField(key, value), s.min)
+}
+
+func (s *stackLogger) WithFields(fields ...any) loggers.Contextual {
+	return NewStackLogger(s.logger.WithFields(fields...), s.min)
+}
@@ -10,7 +10,12 @@ type standardMap struct {
 	LevelMapper
 }
 
+// GetUnderlying returns the logger wrapped by the mapper when it exposes one,
+// otherwise the map itself.
 func (s *standardMap) GetUnderlying() any {
+	if u, ok := s.LevelMapper.(interface{ GetUnderlying() any }); ok {
+		return u.GetUnderlying()
+	}
 	return s
 }
 
@@ -0,0 +1,268 @@
+package stdlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+)
+
+// LevelPlacement tells where the level is written.
+type LevelPlacement int
+
+const (
+	// LevelPrefix writes the level before the message, the default.
+	LevelPrefix LevelPlacement = iota
+	// LevelSuffix writes the level at the end of the line.
+	LevelSuffix
+	// LevelOmitted does not write the level.
+	LevelOmitted
+)
+
+// FieldPlacement tells where the fields are written.
+type FieldPlacement int
+
+const (
+	// FieldsAfter writes the fields after the message, the default.
+	FieldsAfter FieldPlacement = iota
+	// FieldsBefore writes the fields before the message.
+	FieldsBefore
+)
+
+// Quoting tells how field values are quoted.
+type Quoting int
+
+const (
+	// QuoteNever writes values as is, the default.
+	QuoteNever Quoting = iota
+	// QuoteWhenNeeded quotes values which are empty or hold spaces, quotes, equal signs,
+	// the field separator or non printable characters.
+	QuoteWhenNeeded
+	// QuoteAlways quotes every value.
+	QuoteAlways
+)
+
+// Option configures the formatting of a logger created by NewLogger.
+type Option func(*format)
+
+// WithLevel sets the placement of the level.
+func WithLevel(p LevelPlacement) Option {
+	return func(f *format) { f.level = p }
+}
+
+// WithFields sets the placement of the fields.
+func WithFields(p FieldPlacement) Option {
+	return func(f *format) { f.fields = p }
+}
+
+// WithFieldSeparator sets the separator of the fields, ", " by default.
+func WithFieldSeparator(sep string) Option {
+	return func(f *format) { f.separator = sep }
+}
+
+// WithQuoting sets the quoting of field values.
+func WithQuoting(q Quoting) Option {
+	return func(f *format) { f.quoting = q }
+}
+
+// SortKeys writes the fields sorted by key rather than in the order they were added.
+func SortKeys() Option {
+	return func(f *format) { f.sortKeys = true }
+}
+
+// WithTime writes the time of the entries at the start of the line, formatted with layout,
+// in UTC if utc is set or in local time otherwise. The flags of the log.Logger should then
+// not include its own date and time.
+func WithTime(layout string, utc bool) Option {
+	return func(f *format) { f.timeLayout, f.utc = layout, utc }
+}
+
+// EscapeNewlines escapes the line breaks of messages, field keys and values, so that an entry
+// is always written on a single line and cannot forge other entries.
+func EscapeNewlines() Option {
+	return func(f *format) { f.escape = true }
+}
+
+// JSON writes each entry as a JSON object holding the time if WithTime is set, the level,
+// the message as msg and the fields, sorted with SortKeys. Values which cannot be encoded
+// are written as strings. The other options are ignored.
+func JSON() Option {
+	return func(f *format) { f.json = true }
+}
+
+// WithColor colors the level as the console mapper does, for terminals.
+func WithColor() Option {
+	return func(f *format) { f.color = true }
+}
+
+// format is the formatting of a logger created with options.
+type format struct {
+	level      LevelPlacement
+	fields     FieldPlacement
+	separator  string
+	quoting    Quoting
+	sortKeys   bool
+	timeLayout string
+	utc        bool
+	escape     bool
+	color      bool
+	json       bool
+}
+
+func newFormat(opts []Option) *format {
+	f := format{separator: ", "}
+	for _, o := range opts {
+		o(&f)
+	}
+	return &f
+}
+
+var newlineEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+// line returns the line of an entry.
+func (f *format) line(lev mappers.Level, msg string, fields []any) string {
+	if f.json {
+		return f.jsonLine(lev, msg, fields)
+	}
+	var b strings.Builder
+	if f.timeLayout != "" {
+		t := time.Now()
+		if f.utc {
+			t = t.UTC()
+		}
+		b.WriteString(t.Format(f.timeLayout))
+		b.WriteByte(' ')
+	}
+	if f.level == LevelPrefix {
+		b.WriteString(f.levelName(lev))
+	}
+	if f.escape {
+		msg = newlineEscaper.Replace(msg)
+	}
+	postfix := f.postfix(fields)
+	switch {
+	case postfix == "":
+		b.WriteString(msg)
+	case f.fields == FieldsBefore:
+		b.WriteString(postfix)
+		b.WriteByte(' ')
+		b.WriteString(msg)
+	default:
+		b.WriteString(msg)
+		b.WriteByte(' ')
+		b.WriteString(postfix)
+	}
+	if f.level == LevelSuffix {
+		b.WriteByte(' ')
+		b.WriteString(strings.TrimSpace(f.levelName(lev)))
+	}
+	return b.String()
+}
+
+// jsonLine returns the line of an entry encoded as a JSON object.
+func (f *format) jsonLine(lev mappers.Level, msg string, fields []any) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	if f.timeLayout != "" {
+		t := time.Now()
+		if f.utc {
+			t = t.UTC()
+		}
+		writeJSONField(&b, "time", t.Format(f.timeLayout))
+		b.WriteByte(',')
+	}
+	level, _ := lev.MarshalText()
+	writeJSONField(&b, "level", string(level))
+	b.WriteByte(',')
+	writeJSONField(&b, "msg", msg)
+	type kv struct {
+		key   string
+		value any
+	}
+	kvs := make([]kv, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		kvs = append(kvs, kv{fmt.Sprint(fields[i]), fields[i+1]})
+	}
+	if f.sortKeys {
+		slices.SortStableFunc(kvs, func(a, b kv) int { return strings.Compare(a.key, b.key) })
+	}
+	for _, kv := range kvs {
+		b.WriteByte(',')
+		writeJSONField(&b, kv.key, kv.value)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// writeJSONField writes "key":value to b. Errors are written as their message, formatted
+// by fmt so that nil pointers do not panic, and values which cannot be encoded as strings.
+func writeJSONField(b *strings.Builder, key string, value any) {
+	k, _ := json.Marshal(key)
+	b.Write(k)
+	b.WriteByte(':')
+	if err, ok := value.(error); ok {
+		value = fmt.Sprint(err)
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(v)
+}
+
+// levelName returns the padded name of lev, colored if needed.
+func (f *format) levelName(lev mappers.Level) string {
+	name := lev.String()
+	if !f.color {
+		return name
+	}
+	trimmed := strings.TrimRight(name, " ")
+	return console.Paint(lev, trimmed) + name[len(trimmed):]
+}
+
+// postfix returns the bracketed fields.
+func (f *format) postfix(fields []any) string {
+	if len(fields) < 2 {
+		return ""
+	}
+	type kv struct{ key, value string }
+	kvs := make([]kv, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		key := fmt.Sprint(fields[i])
+		if f.escape {
+			key = newlineEscaper.Replace(key)
+		}
+		kvs = append(kvs, kv{key, f.value(fields[i+1])})
+	}
+	if f.sortKeys {
+		slices.SortStableFunc(kvs, func(a, b kv) int { return strings.Compare(a.key, b.key) })
+	}
+	s := make([]string, len(kvs))
+	for i, kv := range kvs {
+		s[i] = kv.key + "=" + kv.value
+	}
+	return "[" + strings.Join(s, f.separator) + "]"
+}
+
+func (f *format) value(v any) string {
+	s := fmt.Sprint(v)
+	switch f.quoting {
+	case QuoteAlways:
+		return strconv.Quote(s)
+	case QuoteWhenNeeded:
+		if s == "" || (f.separator != "" && strings.Contains(s, f.separator)) || strings.ContainsFunc(s, func(r rune) bool {
+			return r == ' ' || r == '"' || r == '=' || !strconv.IsPrint(r)
+		}) {
+			return strconv.Quote(s)
+		}
+	}
+	if f.escape {
+		return newlineEscaper.Replace(s)
+	}
+	return s
+}
@@ -0,0 +1,28 @@
+package stdlib
+
+import (
+	"log"
+	"time"
+
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+	"github.com/marcaudefroy/loggers/mappers/preset"
+)
+
+// NewProduction returns a logger set up for production, see preset.Production.
+// Entries are written as JSON objects with the UTC time, see JSON.
+func NewProduction(opts ...preset.Option) *mappers.LevelFilter {
+	c := preset.Production(opts...)
+	return c.Wrap(NewLogger(log.New(c.Output, "", 0), WithTime(time.RFC3339Nano, true), JSON()))
+}
+
+// NewDevelopment returns a logger set up for development, see preset.Development.
+// Entries start with the local time and the level is colored on terminals.
+func NewDevelopment(opts ...preset.Option) *mappers.LevelFilter {
+	c := preset.Development(opts...)
+	format := []Option{WithTime(console.DefaultTimeLayout, false)}
+	if c.UseColor() {
+		format = append(format, WithColor())
+	}
+	return c.Wrap(NewLogger(log.New(c.Output, "", 0), format...))
+}
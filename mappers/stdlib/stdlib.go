@@ -15,6 +15,8 @@ import (
 type goLog struct {
 	logger *log.Logger
 	fields []any
+	// format is set when the logger was created with options, the legacy formatting is used otherwise.
+	format *format
 }
 
 // NewDefaultLogger returns a Contextual logger using a log.Logger with stderr output.
@@ -29,10 +31,15 @@ func NewDefaultLogger() loggers.Contextual {
 }
 
 // NewLogger creates a Contextual logger from a log.Logger.
-func NewLogger(l *log.Logger) loggers.Contextual {
+// Without options, entries are written as "LEVEL message [key=value, ...]".
+// Options change the formatting, the entries of Println then no longer hold its extra spaces.
+func NewLogger(l *log.Logger, opts ...Option) loggers.Contextual {
 	var g goLog
 	g.logger = l
 	g.fields = []any{}
+	if len(opts) > 0 {
+		g.format = newFormat(opts)
+	}
 	a := mappers.NewContextualMap(&g)
 
 	return a
@@ -44,24 +51,46 @@ func (l *goLog) GetUnderlying() any {
 
 // LevelPrint is a Mapper method
 func (l *goLog) LevelPrint(lev mappers.Level, i ...any) {
+	if l.format != nil {
+		l.output(l.format.line(lev, fmt.Sprint(i...), l.fields))
+		return
+	}
 	v := []any{lev}
 	v = append(v, i...)
-	l.logger.Print(v...)
+	l.output(fmt.Sprint(v...))
 }
 
 // LevelPrintf is a Mapper method
 func (l *goLog) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	if l.format != nil {
+		l.output(l.format.line(lev, fmt.Sprintf(format, i...), l.fields))
+		return
+	}
 	f := "%s" + format
 	v := []any{lev}
 	v = append(v, i...)
-	l.logger.Printf(f, v...)
+	l.output(fmt.Sprintf(f, v...))
 }
 
 // LevelPrintln is a Mapper method
 func (l *goLog) LevelPrintln(lev mappers.Level, i ...any) {
+	if l.format != nil {
+		l.output(l.format.line(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"), l.fields))
+		return
+	}
 	v := []any{lev}
 	v = append(v, i...)
-	l.logger.Println(v...)
+	l.output(fmt.Sprintln(v...))
+}
+
+// output writes s with the log.Logger. When its flags include the file name, the depth given
+// to Output is the one of the logging call, so that the file is not the one of this mapper.
+func (l *goLog) output(s string) {
+	depth := 1
+	if l.logger.Flags()&(log.Lshortfile|log.Llongfile) != 0 {
+		depth = mappers.CallerDepth()
+	}
+	l.logger.Output(depth+1, s)
 }
 
 // WithField returns an Contextual logger with a pre-set field.
@@ -106,12 +135,20 @@ func (r *gologPostfixLogger) postfixFromFields() string {
 }
 
 func (r *gologPostfixLogger) LevelPrint(lev mappers.Level, i ...any) {
+	if r.format != nil {
+		r.goLog.LevelPrint(lev, i...)
+		return
+	}
 	i = append(i, " ", r.postfixFromFields())
 
 	r.goLog.LevelPrint(lev, i...)
 }
 
 func (r *gologPostfixLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	if r.format != nil {
+		r.goLog.LevelPrintf(lev, format, i...)
+		return
+	}
 	format = format + " %s"
 	i = append(i, r.postfixFromFields())
 
@@ -119,6 +156,10 @@ func (r *gologPostfixLogger) LevelPrintf(lev mappers.Level, format string, i ...
 }
 
 func (r *gologPostfixLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	if r.format != nil {
+		r.goLog.LevelPrintln(lev, i...)
+		return
+	}
 	i = append(i, r.postfixFromFields())
 	r.goLog.LevelPrintln(lev, i...)
 }
@@ -2,11 +2,18 @@ package stdlib
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"log"
+	"net/url"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers/console"
+	"github.com/marcaudefroy/loggers/mappers/preset"
 )
 
 func TestLogInterface(t *testing.T) {
@@ -112,3 +119,90 @@ func NewBufferedLog() (loggers.Contextual, *bytes.Buffer) {
 	l := log.New(bb, "", log.Ldate|log.Ltime)
 	return NewLogger(l), bb
 }
+
+func TestLogFormatOptions(t *testing.T) {
+	tests := []struct {
+		opts     []Option
+		expected string
+	}{
+		{[]Option{WithLevel(LevelPrefix)}, "WARN  disk full [b=2, a=x y]\n"},
+		{[]Option{WithLevel(LevelSuffix)}, "disk full [b=2, a=x y] WARN\n"},
+		{[]Option{WithLevel(LevelOmitted), WithFields(FieldsBefore)}, "[b=2, a=x y] disk full\n"},
+		{[]Option{WithFieldSeparator(" "), WithQuoting(QuoteWhenNeeded), SortKeys()}, "WARN  disk full [a=\"x y\" b=2]\n"},
+		{[]Option{WithQuoting(QuoteAlways)}, "WARN  disk full [b=\"2\", a=\"x y\"]\n"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		NewLogger(log.New(&b, "", 0), test.opts...).WithFields("b", 2, "a", "x y").Warnln("disk", "full")
+		if b.String() != test.expected {
+			t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), test.expected)
+		}
+	}
+}
+
+func TestLogFormatTimeAndEscaping(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(log.New(&b, "", 0), WithTime(time.RFC3339, true), EscapeNewlines())
+
+	l.WithFields("v", "a\nb", "k\nINFO  x", 1).Infof("forged\nINFO  %s", "entry")
+	pattern := `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ INFO  forged\\nINFO  entry \[v=a\\nb, k\\nINFO  x=1\]` + "\n$"
+	if !regexp.MustCompile(pattern).MatchString(b.String()) {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), pattern)
+	}
+
+	b.Reset()
+	before := time.Now().Format("15:04") + " INFO  local\n"
+	NewLogger(log.New(&b, "", 0), WithTime("15:04", false)).Info("local")
+	if after := time.Now().Format("15:04") + " INFO  local\n"; b.String() != before && b.String() != after {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), after)
+	}
+}
+
+func TestLogPresets(t *testing.T) {
+	var b bytes.Buffer
+	l := NewProduction(preset.Output(&b), preset.Fields("app", "demo", "err", errors.New("boom")))
+	l.Debug("hidden")
+	l.Infof("started in %s", "1 s")
+	var entry map[string]any
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("Unmarshal of %q failed: %v", b.String(), err)
+	}
+	expected := map[string]any{"level": "info", "msg": "started in 1 s", "app": "demo", "err": "boom"}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Field %s mismatch %v (actual) != %v (expected)", k, entry[k], v)
+		}
+	}
+	if ts, _ := entry["time"].(string); !strings.HasSuffix(ts, "Z") {
+		t.Errorf("Time mismatch %q (actual) != UTC time (expected)", ts)
+	}
+	if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "stdlib/stdlib_test.go:") {
+		t.Errorf("Caller mismatch %q (actual) != stdlib/stdlib_test.go:<line> (expected)", caller)
+	}
+
+	b.Reset()
+	l = NewDevelopment(preset.Output(&b), preset.Color(console.ColorAlways), preset.Caller(false))
+	l.Debug("shown")
+	l.Warn("careful")
+	out := b.String()
+	if !strings.Contains(out, "\x1b[35mDEBUG\x1b[0m shown\n") {
+		t.Errorf("Log output mismatch %q (actual) != colored debug entry (expected)", out)
+	}
+	if !strings.Contains(out, "\x1b[33mWARN\x1b[0m  careful [stack=github.com/marcaudefroy/loggers/mappers/stdlib.TestLogPresets\n\t") {
+		t.Errorf("Log output mismatch %q (actual) != colored warn entry with stack (expected)", out)
+	}
+}
+
+func TestLogJSONNilError(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(log.New(&b, "", 0), JSON())
+	var err *url.Error
+	l.WithField("err", err).Info("nil")
+	var entry map[string]any
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("Unmarshal of %q failed: %v", b.String(), err)
+	}
+	if entry["err"] != "<nil>" {
+		t.Errorf("Field err mismatch %v (actual) != <nil> (expected)", entry["err"])
+	}
+}
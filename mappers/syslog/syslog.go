@@ -0,0 +1,277 @@
+// Package syslog maps a Contextual logger to syslog messages in the RFC 5424 or RFC 3164 format,
+// sent over a unix socket, UDP or TCP.
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/output"
+)
+
+// Format is a syslog message format.
+type Format int
+
+const (
+	// RFC5424 is the structured syslog format, with fields as structured data.
+	RFC5424 Format = iota
+	// RFC3164 is the legacy BSD syslog format, with fields appended to the message as key=value.
+	RFC3164
+)
+
+// Facility is a syslog facility.
+type Facility int
+
+// Syslog facilities.
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	Lpr
+	News
+	Uucp
+	Cron
+	Authpriv
+	Ftp
+	Local0 Facility = iota + 4
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// DefaultStructuredDataID is the SD-ID holding the fields in RFC 5424 messages.
+const DefaultStructuredDataID = "fields@32473"
+
+// localSockets are the usual paths of the local syslog daemon socket.
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Options configures a Writer. The zero value is usable.
+type Options struct {
+	// Format defaults to RFC5424.
+	Format Format
+	// Facility defaults to User. As a consequence Kern cannot be used,
+	// which is reserved to the kernel anyway.
+	Facility Facility
+	// AppName defaults to the base name of the executable.
+	AppName string
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// StructuredDataID defaults to DefaultStructuredDataID.
+	StructuredDataID string
+	// Timeout bounds dialing and each write, 0 means no timeout.
+	Timeout time.Duration
+}
+
+// Writer sends syslog messages to a syslog server. Datagram networks send one message per datagram,
+// stream networks frame messages by octet counting (RFC 6587). On failure, the connection is
+// re-established once per message. It is safe for concurrent use.
+type Writer struct {
+	conn   *output.Network
+	stream bool
+	opts   Options
+	pid    string
+}
+
+// Dial returns a Writer for the given network ("unixgram", "unix", "udp" or "tcp") and address.
+// An empty network connects to the local syslog daemon socket.
+func Dial(network, address string, opts Options) (*Writer, error) {
+	if network == "" {
+		var err error
+		if network, address, err = localSocket(opts.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	w := Writer{opts: opts, pid: strconv.Itoa(os.Getpid())}
+	switch network {
+	case "unixgram", "udp", "udp4", "udp6":
+	case "unix", "tcp", "tcp4", "tcp6":
+		w.stream = true
+	default:
+		return nil, fmt.Errorf("syslog: unsupported network %q", network)
+	}
+	if w.opts.Facility == Kern {
+		w.opts.Facility = User
+	}
+	if w.opts.AppName == "" {
+		w.opts.AppName = filepath.Base(os.Args[0])
+	}
+	if w.opts.Hostname == "" {
+		w.opts.Hostname, _ = os.Hostname()
+	}
+	if w.opts.StructuredDataID == "" {
+		w.opts.StructuredDataID = DefaultStructuredDataID
+	}
+	w.conn = output.NewNetwork(network, address, opts.Timeout)
+	return &w, nil
+}
+
+func localSocket(timeout time.Duration) (network, address string, err error) {
+	for _, path := range localSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if c, err := net.DialTimeout(network, path, timeout); err == nil {
+				c.Close()
+				return network, path, nil
+			}
+		}
+	}
+	return "", "", errors.New("syslog: no local syslog socket found")
+}
+
+// Close closes the connection. A later message reconnects.
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
+
+// Severity returns the syslog severity of a level.
+func Severity(lev mappers.Level) int {
+	switch lev {
+	case mappers.LevelDebug:
+		return 7
+	case mappers.LevelInfo:
+		return 6
+	case mappers.LevelWarn:
+		return 4
+	case mappers.LevelError:
+		return 3
+	case mappers.LevelFatal:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Write sends one message of level lev with the given fields.
+func (w *Writer) Write(t time.Time, lev mappers.Level, message string, fields []any) error {
+	m := w.format(t, lev, message, fields)
+	if w.stream {
+		m = strconv.Itoa(len(m)) + " " + m
+	}
+	_, err := w.conn.Write([]byte(m))
+	return err
+}
+
+// timestampLayout is the RFC 5424 TIMESTAMP, whose TIME-SECFRAC has at most 6 digits.
+const timestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+func (w *Writer) format(t time.Time, lev mappers.Level, message string, fields []any) string {
+	pri := int(w.opts.Facility)*8 + Severity(lev)
+	var b strings.Builder
+	if w.opts.Format == RFC3164 {
+		fmt.Fprintf(&b, "<%d>%s %s %s[%s]: %s", pri, t.Format(time.Stamp), w.opts.Hostname, w.opts.AppName, w.pid, message)
+		for i := 0; i+1 < len(fields); i = i + 2 {
+			fmt.Fprintf(&b, " %v=%v", fields[i], fields[i+1])
+		}
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - ", pri, t.Format(timestampLayout),
+		header(w.opts.Hostname, 255), header(w.opts.AppName, 48), header(w.pid, 128))
+	if len(fields) < 2 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + w.opts.StructuredDataID)
+		for i := 0; i+1 < len(fields); i = i + 2 {
+			fmt.Fprintf(&b, ` %s="%s"`, paramName(fmt.Sprint(fields[i])), paramEscaper.Replace(fmt.Sprint(fields[i+1])))
+		}
+		b.WriteString("]")
+	}
+	if message != "" {
+		b.WriteString(" " + message)
+	}
+	return b.String()
+}
+
+// header returns s as a RFC 5424 header field: printable ASCII, at most n characters, "-" when empty.
+func header(s string, n int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > n {
+		s = s[:n]
+	}
+	return s
+}
+
+// paramName returns key as a RFC 5424 SD-PARAM name.
+func paramName(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+	if key == "" {
+		return "_"
+	}
+	if len(key) > 32 {
+		key = key[:32]
+	}
+	return key
+}
+
+var paramEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogLogger maps a Writer to a Contextual logger interface.
+type syslogLogger struct {
+	writer *Writer
+	fields []any
+}
+
+// NewLogger returns a Contextual logger sending its entries to w.
+// Write errors are reported on stderr, as there is nowhere else to log them.
+func NewLogger(w *Writer) loggers.Contextual {
+	return mappers.NewContextualMap(&syslogLogger{writer: w})
+}
+
+func (l *syslogLogger) GetUnderlying() any {
+	return l.writer
+}
+
+// LevelPrint is a Mapper method
+func (l *syslogLogger) LevelPrint(lev mappers.Level, i ...any) {
+	if err := l.writer.Write(time.Now(), lev, fmt.Sprint(i...), l.fields); err != nil {
+		fmt.Fprintf(os.Stderr, "syslog: %v\n", err)
+	}
+}
+
+// LevelPrintf is a Mapper method
+func (l *syslogLogger) LevelPrintf(lev mappers.Level, format string, i ...any) {
+	l.LevelPrint(lev, fmt.Sprintf(format, i...))
+}
+
+// LevelPrintln is a Mapper method
+func (l *syslogLogger) LevelPrintln(lev mappers.Level, i ...any) {
+	l.LevelPrint(lev, strings.TrimSuffix(fmt.Sprintln(i...), "\n"))
+}
+
+// WithField returns an Contextual logger with a pre-set field.
+func (l *syslogLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+// WithFields returns an Contextual logger with pre-set fields.
+func (l *syslogLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := syslogLogger{writer: l.writer, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...)}
+	return mappers.NewContextualMap(&nl)
+}
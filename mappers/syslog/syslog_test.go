@@ -0,0 +1,128 @@
+package syslog
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+)
+
+func TestSyslogInterface(t *testing.T) {
+	var _ loggers.Contextual = NewLogger(&Writer{})
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	return string(b[:n])
+}
+
+func TestSyslogRFC5424UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := Dial("udp", conn.LocalAddr().String(), Options{Facility: Local0, AppName: "app", Hostname: "host"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+	l := NewLogger(w)
+
+	l.WithFields("user id", "bob", "quote", `a"b]`).Warnf("disk %d%% full", 90)
+	pattern := `^<132>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) host app \d+ - \[fields@32473 user_id="bob" quote="a\\"b\\]"\] disk 90% full$`
+	if m := readDatagram(t, conn); !regexp.MustCompile(pattern).MatchString(m) {
+		t.Errorf("Message mismatch %q (actual) != %q (expected)", m, pattern)
+	}
+
+	l.Debug("no fields")
+	pattern = `^<135>1 \S+ host app \d+ - - no fields$`
+	if m := readDatagram(t, conn); !regexp.MustCompile(pattern).MatchString(m) {
+		t.Errorf("Message mismatch %q (actual) != %q (expected)", m, pattern)
+	}
+}
+
+func TestSyslogRFC3164Unixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := Dial("unixgram", path, Options{Format: RFC3164, AppName: "app", Hostname: "host"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+
+	NewLogger(w).WithField("id", 7).Errorln("failed", "twice")
+	pattern := `^<11>\w{3} [ \d]\d \d\d:\d\d:\d\d host app\[\d+\]: failed twice id=7$`
+	if m := readDatagram(t, conn); !regexp.MustCompile(pattern).MatchString(m) {
+		t.Errorf("Message mismatch %q (actual) != %q (expected)", m, pattern)
+	}
+}
+
+func TestSyslogTCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	messages := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			length, err := r.ReadString(' ')
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			b := make([]byte, n)
+			if _, err := r.Read(b); err == nil {
+				messages <- string(b)
+			}
+			// Drop the connection after each message to force reconnections.
+			conn.Close()
+		}
+	}()
+
+	w, err := Dial("tcp", ln.Addr().String(), Options{AppName: "app", Hostname: "host", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer w.Close()
+	l := NewLogger(w)
+
+	for _, msg := range []string{"first", "second"} {
+		l.Info(msg)
+		select {
+		case m := <-messages:
+			if !strings.HasPrefix(m, "<14>1 ") || !strings.HasSuffix(m, " - - "+msg) {
+				t.Errorf("Message mismatch %q", m)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Message %q not received", msg)
+		}
+		// Let the server close the connection before the next message.
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,169 @@
+// Package metrics counts log entries and bytes per level and logger name, and exposes
+// the counts through expvar and the Prometheus text exposition format.
+package metrics
+
+import (
+	"cmp"
+	"expvar"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+// Metrics holds the counters of the loggers created by NewLogger. It is safe for concurrent use.
+type Metrics struct {
+	mu       sync.RWMutex
+	counters map[key]*counter
+}
+
+type key struct {
+	level  mappers.Level
+	logger string
+}
+
+type counter struct {
+	entries atomic.Uint64
+	bytes   atomic.Uint64
+}
+
+// Sample is the count of entries and message bytes of a level and logger name.
+// Message bytes are the length of the formatted messages, without the line break of Println.
+type Sample struct {
+	Level   mappers.Level `json:"level"`
+	Logger  string        `json:"logger,omitempty"`
+	Entries uint64        `json:"entries"`
+	Bytes   uint64        `json:"bytes"`
+}
+
+// New returns empty Metrics.
+func New() *Metrics {
+	return &Metrics{counters: make(map[key]*counter)}
+}
+
+// NewLogger returns a Contextual logger counting in m the entries passed to l.
+// The logger name is taken from the named.NameKey field, so that the counts are split by
+// named logger when NewLogger wraps the logger given to named.New.
+// Entries dropped before reaching it, e.g. by a level filter wrapping it, are not counted.
+func NewLogger(l loggers.Contextual, m *Metrics) loggers.Contextual {
+	return mappers.NewContextualMap(&metricsLogger{logger: l, metrics: m})
+}
+
+// Samples returns the counts, sorted by logger name then level.
+func (m *Metrics) Samples() []Sample {
+	m.mu.RLock()
+	samples := make([]Sample, 0, len(m.counters))
+	for k, c := range m.counters {
+		samples = append(samples, Sample{Level: k.level, Logger: k.logger, Entries: c.entries.Load(), Bytes: c.bytes.Load()})
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(samples, func(a, b Sample) int {
+		return cmp.Or(strings.Compare(a.Logger, b.Logger), cmp.Compare(a.Level, b.Level))
+	})
+	return samples
+}
+
+// Publish exposes the samples as the expvar variable name.
+// Like expvar.Publish, it panics if name is already used.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return m.Samples()
+	}))
+}
+
+// ServeHTTP writes the counts in the Prometheus text exposition format,
+// as the log_entries_total and log_bytes_total counters labeled by level and logger.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	samples := m.Samples()
+
+	var b strings.Builder
+	b.WriteString("# HELP log_entries_total Number of log entries by level and logger.\n")
+	b.WriteString("# TYPE log_entries_total counter\n")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "log_entries_total{%s} %d\n", labels(s), s.Entries)
+	}
+	b.WriteString("# HELP log_bytes_total Number of log message bytes by level and logger.\n")
+	b.WriteString("# TYPE log_bytes_total counter\n")
+	for _, s := range samples {
+		fmt.Fprintf(&b, "log_bytes_total{%s} %d\n", labels(s), s.Bytes)
+	}
+	w.Write([]byte(b.String()))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(s Sample) string {
+	level, _ := s.Level.MarshalText()
+	return fmt.Sprintf(`level="%s",logger="%s"`, level, labelEscaper.Replace(s.Logger))
+}
+
+func (m *Metrics) counter(k key) *counter {
+	m.mu.RLock()
+	c, ok := m.counters[k]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.counters[k]; !ok {
+		c = new(counter)
+		m.counters[k] = c
+	}
+	return c
+}
+
+func (m *Metrics) add(lev mappers.Level, logger string, message string) {
+	c := m.counter(key{level: lev, logger: logger})
+	c.entries.Add(1)
+	c.bytes.Add(uint64(len(message)))
+}
+
+type metricsLogger struct {
+	logger  loggers.Contextual
+	name    string
+	metrics *Metrics
+}
+
+func (l *metricsLogger) GetUnderlying() any {
+	return l.logger.GetUnderlying()
+}
+
+func (l *metricsLogger) LevelPrint(lev mappers.Level, v ...any) {
+	l.metrics.add(lev, l.name, fmt.Sprint(v...))
+	mappers.Dispatch(l.logger, lev, v...)
+}
+
+func (l *metricsLogger) LevelPrintf(lev mappers.Level, format string, v ...any) {
+	l.metrics.add(lev, l.name, fmt.Sprintf(format, v...))
+	mappers.Dispatchf(l.logger, lev, format, v...)
+}
+
+func (l *metricsLogger) LevelPrintln(lev mappers.Level, v ...any) {
+	// The line break added by Sprintln ends the entry and is not part of the message.
+	l.metrics.add(lev, l.name, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+	mappers.Dispatchln(l.logger, lev, v...)
+}
+
+func (l *metricsLogger) WithField(key string, value any) loggers.Contextual {
+	return l.WithFields(key, value)
+}
+
+func (l *metricsLogger) WithFields(fields ...any) loggers.Contextual {
+	nl := metricsLogger{logger: l.logger.WithFields(fields...), name: l.name, metrics: l.metrics}
+	for i := 0; i+1 < len(fields); i = i + 2 {
+		if k, ok := fields[i].(string); ok && k == named.NameKey {
+			nl.name = fmt.Sprint(fields[i+1])
+		}
+	}
+	return mappers.NewContextualMap(&nl)
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+	"github.com/marcaudefroy/loggers/named"
+)
+
+func TestMetricsInterface(t *testing.T) {
+	var _ loggers.Contextual = NewLogger(stdlib.NewDefaultLogger(), New())
+}
+
+func newCountedLog(m *Metrics) (*named.Logger, *bytes.Buffer) {
+	b := new(bytes.Buffer)
+	l := NewLogger(stdlib.NewLogger(log.New(b, "", 0)), m)
+	return named.New(l, named.NewRegistry(mappers.LevelInfo)), b
+}
+
+func TestMetricsCounts(t *testing.T) {
+	m := New()
+	l, b := newCountedLog(m)
+
+	l.Info("1234")
+	l.Debug("filtered")
+	l.Named("db").WithField("id", 1).Errorf("%d", 42)
+	l.Named("db").Error("x")
+	l.Warnln("abc")
+
+	if !strings.Contains(b.String(), "INFO  1234") {
+		t.Errorf("Log output %q must contain the entry", b.String())
+	}
+	expected := []Sample{
+		{Level: mappers.LevelInfo, Logger: "", Entries: 1, Bytes: 4},
+		{Level: mappers.LevelWarn, Logger: "", Entries: 1, Bytes: 3},
+		{Level: mappers.LevelError, Logger: "db", Entries: 2, Bytes: 3},
+	}
+	samples := m.Samples()
+	if len(samples) != len(expected) {
+		t.Fatalf("Samples mismatch %+v (actual) != %+v (expected)", samples, expected)
+	}
+	for i := range expected {
+		if samples[i] != expected[i] {
+			t.Errorf("Sample mismatch %+v (actual) != %+v (expected)", samples[i], expected[i])
+		}
+	}
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	m := New()
+	l, _ := newCountedLog(m)
+	l.Named(`we"ird`).Warn("abc")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, expected := range []string{
+		"# TYPE log_entries_total counter\n",
+		`log_entries_total{level="warn",logger="we\"ird"} 1` + "\n",
+		`log_bytes_total{level="warn",logger="we\"ird"} 3` + "\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Exposition %q does not contain %q", body, expected)
+		}
+	}
+}
+
+func TestMetricsExpvar(t *testing.T) {
+	m := New()
+	m.Publish("test_log_metrics")
+	l, _ := newCountedLog(m)
+	l.Info("abc")
+
+	var samples []Sample
+	if err := json.Unmarshal([]byte(expvar.Get("test_log_metrics").String()), &samples); err != nil {
+		t.Fatalf("Failed to decode expvar: %v", err)
+	}
+	if len(samples) != 1 || samples[0].Entries != 1 || samples[0].Level != mappers.LevelInfo {
+		t.Errorf("Expvar samples mismatch %+v", samples)
+	}
+}
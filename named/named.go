@@ -0,0 +1,137 @@
+// Package named provides hierarchical named loggers whose levels are set per name.
+package named
+
+import (
+	"sync/atomic"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// NameKey is the field key holding the name of a named logger.
+const NameKey = "logger"
+
+// Logger is a Contextual logger with a dotted hierarchical name, emitted as the NameKey field,
+// and a minimum level resolved by name in a Registry.
+type Logger struct {
+	*mappers.ContextualMap
+	n *namedLogger
+}
+
+// New returns the root logger, without a name, of the hierarchy of loggers
+// mapped to l and leveled by r. A nil r uses DefaultRegistry.
+func New(l loggers.Contextual, r *Registry) *Logger {
+	if r == nil {
+		r = DefaultRegistry
+	}
+	return newLogger(l, "", r)
+}
+
+// Named returns the child of l called name. If l is not a *Logger,
+// it is first made the root of a hierarchy using DefaultRegistry.
+func Named(l loggers.Contextual, name string) *Logger {
+	n, ok := l.(*Logger)
+	if !ok {
+		n = New(l, nil)
+	}
+	return n.Named(name)
+}
+
+func newLogger(base loggers.Contextual, name string, r *Registry) *Logger {
+	n := namedLogger{base: base, logger: base, name: name, registry: r}
+	if name != "" {
+		n.logger = base.WithField(NameKey, name)
+	}
+	l := &Logger{n: &n}
+	l.ContextualMap = mappers.NewContextualMap(l.n)
+	return l
+}
+
+// Named returns a child logger, whose name is the name of l and name joined by a dot.
+// The child keeps the fields of l.
+func (l *Logger) Named(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	if l.n.name != "" {
+		name = l.n.name + "." + name
+	}
+	return newLogger(l.n.base, name, l.n.registry)
+}
+
+// Name returns the dotted name of the logger, empty for a root logger.
+func (l *Logger) Name() string {
+	return l.n.name
+}
+
+// Registry returns the registry holding the level of the logger.
+func (l *Logger) Registry() *Registry {
+	return l.n.registry
+}
+
+// Level returns the effective level of the logger.
+func (l *Logger) Level() mappers.Level {
+	return l.n.level()
+}
+
+// SetLevel sets the level of the logger name in its registry,
+// which also applies to its children without a level of their own.
+func (l *Logger) SetLevel(lev mappers.Level) {
+	l.n.registry.SetLevel(l.n.name, lev)
+}
+
+type namedLogger struct {
+	// base is the mapped logger with the fields, but without the name field.
+	base     loggers.Contextual
+	logger   loggers.Contextual
+	name     string
+	registry *Registry
+
+	// cache holds the level resolved for a registry version.
+	cache atomic.Pointer[cachedLevel]
+}
+
+type cachedLevel struct {
+	version uint64
+	level   mappers.Level
+}
+
+func (n *namedLogger) level() mappers.Level {
+	v := n.registry.version.Load()
+	if c := n.cache.Load(); c != nil && c.version == v {
+		return c.level
+	}
+	l := n.registry.Level(n.name)
+	n.cache.Store(&cachedLevel{version: v, level: l})
+	return l
+}
+
+func (n *namedLogger) GetUnderlying() any {
+	return n.base.GetUnderlying()
+}
+
+func (n *namedLogger) LevelPrint(lev mappers.Level, v ...any) {
+	if lev >= n.level() {
+		mappers.Dispatch(n.logger, lev, v...)
+	}
+}
+
+func (n *namedLogger) LevelPrintf(lev mappers.Level, format string, v ...any) {
+	if lev >= n.level() {
+		mappers.Dispatchf(n.logger, lev, format, v...)
+	}
+}
+
+func (n *namedLogger) LevelPrintln(lev mappers.Level, v ...any) {
+	if lev >= n.level() {
+		mappers.Dispatchln(n.logger, lev, v...)
+	}
+}
+
+func (n *namedLogger) WithField(key string, value any) loggers.Contextual {
+	return n.WithFields(key, value)
+}
+
+func (n *namedLogger) WithFields(fields ...any) loggers.Contextual {
+	return newLogger(n.base.WithFields(fields...), n.name, n.registry)
+}
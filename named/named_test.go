@@ -0,0 +1,94 @@
+package named
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+func TestNamedInterface(t *testing.T) {
+	var _ loggers.Contextual = &Logger{}
+	var _ mappers.LevelSetter = &Logger{}
+}
+
+func newBufferedLog(r *Registry) (*Logger, *bytes.Buffer) {
+	b := new(bytes.Buffer)
+	return New(stdlib.NewLogger(log.New(b, "", 0)), r), b
+}
+
+func TestRegistryInheritance(t *testing.T) {
+	r := NewRegistry(mappers.LevelInfo)
+	if err := r.SetDirectives("warn, db=debug ,http.client=error"); err != nil {
+		t.Fatalf("SetDirectives failed: %v", err)
+	}
+	tests := map[string]mappers.Level{
+		"":                 mappers.LevelWarn,
+		"db":               mappers.LevelDebug,
+		"db.pool":          mappers.LevelDebug,
+		"dbx":              mappers.LevelWarn,
+		"http":             mappers.LevelWarn,
+		"http.client":      mappers.LevelError,
+		"http.client.pool": mappers.LevelError,
+	}
+	for name, expected := range tests {
+		if l := r.Level(name); l != expected {
+			t.Errorf("Level of %q mismatch %v (actual) != %v (expected)", name, l, expected)
+		}
+	}
+	if d := r.Directives(); d != "warn,db=debug,http.client=error" {
+		t.Errorf("Directives mismatch %q", d)
+	}
+	if err := r.SetDirectives("info,db=loud"); err == nil {
+		t.Errorf("SetDirectives must fail on unknown levels")
+	}
+	if d := r.Directives(); d != "warn,db=debug,http.client=error" {
+		t.Errorf("A failed SetDirectives must not change the registry, got %q", d)
+	}
+}
+
+func TestNamedOutput(t *testing.T) {
+	l, b := newBufferedLog(NewRegistry(mappers.LevelDebug))
+	Named(l.WithField("id", 1), "http").Named("client").Info("request")
+
+	expected := "INFO  request [id=1, logger=http.client]\n"
+	if b.String() != expected {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), expected)
+	}
+}
+
+func TestNamedLevelsAtRuntime(t *testing.T) {
+	r := NewRegistry(mappers.LevelInfo)
+	root, b := newBufferedLog(r)
+	db := root.Named("db")
+	pool := db.Named("pool").WithField("size", 3)
+
+	pool.Debug("hidden")
+	r.SetLevel("db", mappers.LevelDebug)
+	pool.Debug("shown")
+	root.Debug("hidden")
+	db.SetLevel(mappers.LevelError)
+	pool.Warn("hidden")
+	r.Unset("db")
+	pool.Info("shown again")
+
+	s := b.String()
+	if strings.Contains(s, "hidden") {
+		t.Errorf("Log output %q must not contain filtered entries", s)
+	}
+	if strings.Count(s, "shown") != 2 {
+		t.Errorf("Log output %q must contain the 2 enabled entries", s)
+	}
+}
+
+func TestNamedPlainLogger(t *testing.T) {
+	b := new(bytes.Buffer)
+	l := Named(stdlib.NewLogger(log.New(b, "", 0)), "jobs")
+	if l.Name() != "jobs" || l.Registry() != DefaultRegistry {
+		t.Errorf("Named on a plain logger must use the default registry, got %q", l.Name())
+	}
+}
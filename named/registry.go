@@ -0,0 +1,141 @@
+package named
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Registry holds the minimum levels of named loggers.
+// A logger without a level of its own inherits the level of its closest dotted parent,
+// e.g. "http.client" inherits from "http", and ultimately the root level.
+// Changes apply immediately to every logger using the registry. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	root   mappers.Level
+	levels map[string]mappers.Level
+
+	// version is incremented on every change so that loggers can cache their level.
+	version atomic.Uint64
+}
+
+// DefaultRegistry is the registry used by Named and by the default global logger of the log package.
+var DefaultRegistry = NewRegistry(mappers.LevelDebug)
+
+// NewRegistry returns a Registry with root as root level and no named levels.
+func NewRegistry(root mappers.Level) *Registry {
+	return &Registry{root: root, levels: make(map[string]mappers.Level)}
+}
+
+// Level returns the effective level of the logger named name.
+func (r *Registry) Level(name string) mappers.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name != "" {
+		if l, ok := r.levels[name]; ok {
+			return l
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return r.root
+}
+
+// SetLevel sets the level of the logger named name and of its children without a level of their own.
+// The empty name sets the root level.
+func (r *Registry) SetLevel(name string, l mappers.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == "" {
+		r.root = l
+	} else {
+		r.levels[name] = l
+	}
+	r.version.Add(1)
+}
+
+// Unset removes the level of the logger named name, which then inherits the level of its parent.
+// It reports whether name had a level. The root level cannot be unset.
+func (r *Registry) Unset(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.levels[name]
+	delete(r.levels, name)
+	r.version.Add(1)
+	return ok
+}
+
+// Root returns the root level.
+func (r *Registry) Root() mappers.Level {
+	return r.Level("")
+}
+
+// Levels returns a copy of the levels set by name, without the root level.
+func (r *Registry) Levels() map[string]mappers.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.levels)
+}
+
+// SetDirectives replaces all the levels of the registry by the ones described by directives,
+// a comma separated list of either a level, setting the root level, or name=level,
+// e.g. "info,db=debug,http.client=warn". On error the registry is left unchanged.
+func (r *Registry) SetDirectives(directives string) error {
+	root := mappers.LevelDebug
+	levels := make(map[string]mappers.Level)
+	for _, d := range strings.Split(directives, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		name, level, found := strings.Cut(d, "=")
+		if !found {
+			name, level = "", name
+		}
+		name = strings.TrimSpace(name)
+		l, err := mappers.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("directive %q: %w", d, err)
+		}
+		if name == "" {
+			root = l
+		} else {
+			levels[name] = l
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.root, r.levels = root, levels
+	r.version.Add(1)
+	return nil
+}
+
+// Directives returns the levels of the registry in the format accepted by SetDirectives,
+// with names sorted.
+func (r *Registry) Directives() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d := []string{levelName(r.root)}
+	for _, name := range slices.Sorted(maps.Keys(r.levels)) {
+		d = append(d, name+"="+levelName(r.levels[name]))
+	}
+	return strings.Join(d, ",")
+}
+
+func levelName(l mappers.Level) string {
+	text, _ := l.MarshalText()
+	return string(text)
+}
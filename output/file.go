@@ -0,0 +1,349 @@
+// Package output provides io.Writer destinations for mapped loggers:
+// rotating files and network connections.
+package output
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp inserted in the name of rotated files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is appended to the name of compressed rotated files.
+const compressSuffix = ".gz"
+
+// File is an io.Writer appending to a file and rotating it by size and/or time.
+// Rotated files are renamed with their rotation time inserted before the extension,
+// e.g. app-2006-01-02T15-04-05.000.log, and optionally gzipped in the background.
+// It is safe for concurrent use, and can be shared by several loggers.
+type File struct {
+	path        string
+	maxSize     int64
+	maxBackups  int
+	maxAge      time.Duration
+	rotateEvery time.Duration
+	compress    bool
+	perm        os.FileMode
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	nextRotate time.Time
+	// closed is set by Close. Otherwise a nil file is reopened by the next write,
+	// so that a failed rotation does not stop the logging.
+	closed bool
+
+	// compressing tracks the background compressions, waited for by Close.
+	compressing sync.WaitGroup
+}
+
+// FileOption configures a File.
+type FileOption func(*File)
+
+// MaxSize rotates the file before a write would make it larger than n bytes. 0 disables size rotation.
+func MaxSize(n int64) FileOption {
+	return func(f *File) {
+		f.maxSize = n
+	}
+}
+
+// MaxBackups keeps at most n rotated files, removing the oldest ones. 0 keeps them all.
+func MaxBackups(n int) FileOption {
+	return func(f *File) {
+		f.maxBackups = n
+	}
+}
+
+// MaxAge removes the rotated files older than d. 0 keeps them regardless of their age.
+func MaxAge(d time.Duration) FileOption {
+	return func(f *File) {
+		f.maxAge = d
+	}
+}
+
+// RotateEvery rotates the file on the first write after each multiple of d since the zero time,
+// e.g. every hour on the hour, or every day at midnight UTC. 0 disables time rotation.
+func RotateEvery(d time.Duration) FileOption {
+	return func(f *File) {
+		f.rotateEvery = d
+	}
+}
+
+// Compress gzips the rotated files in the background.
+func Compress() FileOption {
+	return func(f *File) {
+		f.compress = true
+	}
+}
+
+// Perm sets the permissions of the created files, 0644 by default. Missing directories are created with 0755.
+func Perm(perm os.FileMode) FileOption {
+	return func(f *File) {
+		f.perm = perm
+	}
+}
+
+// NewFile opens, or creates, the file at path for appending.
+func NewFile(path string, opts ...FileOption) (*File, error) {
+	f := File{path: path, perm: 0o644}
+	for _, opt := range opts {
+		opt(&f)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Write appends p to the file, rotating it first if needed.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it as a backup and opens a new one.
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file at its path, without renaming it.
+// It lets external tools such as logrotate move the file away, see ReopenOnSIGHUP.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Close closes the file and waits for the background compressions.
+// Subsequent writes fail with os.ErrClosed.
+func (f *File) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+
+	f.compressing.Wait()
+	return err
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, f.perm)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	if f.rotateEvery > 0 {
+		f.nextRotate = time.Now().Truncate(f.rotateEvery).Add(f.rotateEvery)
+	}
+	return nil
+}
+
+// shouldRotate reports whether the file must be rotated before writing n bytes. Empty files are never rotated.
+func (f *File) shouldRotate(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+int64(n) > f.maxSize {
+		return true
+	}
+	return f.rotateEvery > 0 && !time.Now().Before(f.nextRotate)
+}
+
+// rotate renames the file as a backup and opens a new one. If the file cannot be renamed,
+// it is reopened and writes keep appending to it. If the new file cannot be opened, the
+// next write tries again.
+func (f *File) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	backup := f.backupName(time.Now())
+	for t := time.Now(); fileExists(backup) || fileExists(backup+compressSuffix); t = t.Add(time.Millisecond) {
+		backup = f.backupName(t)
+	}
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return errors.Join(fmt.Errorf("rotating %s: %w", f.path, err), f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	if f.compress && fileExists(backup) {
+		f.compressing.Add(1)
+		go func() {
+			defer f.compressing.Done()
+			compressFile(backup, f.perm)
+		}()
+	}
+	return f.removeBackups()
+}
+
+func (f *File) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backup is a rotated file. While it is being compressed, both the file and its compressed
+// copy exist: path is then the file, and paths holds both.
+type backup struct {
+	path  string
+	paths []string
+	time  time.Time
+}
+
+// backups returns the rotated files of f, compressed or not, oldest first.
+func (f *File) backups() ([]backup, error) {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	byTime := make(map[time.Time]int)
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), compressSuffix)
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(f.path), e.Name())
+		if i, ok := byTime[t]; ok {
+			if !strings.HasSuffix(path, compressSuffix) {
+				backups[i].path = path
+			}
+			backups[i].paths = append(backups[i].paths, path)
+			continue
+		}
+		byTime[t] = len(backups)
+		backups = append(backups, backup{path: path, paths: []string{path}, time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.Before(backups[j].time)
+	})
+	return backups, nil
+}
+
+func (f *File) removeBackups() error {
+	if f.maxBackups <= 0 && f.maxAge <= 0 {
+		return nil
+	}
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+	remove := 0
+	if f.maxBackups > 0 && len(backups) > f.maxBackups {
+		remove = len(backups) - f.maxBackups
+	}
+	if f.maxAge > 0 {
+		oldest := time.Now().Add(-f.maxAge)
+		for remove < len(backups) && backups[remove].time.Before(oldest) {
+			remove++
+		}
+	}
+	for _, b := range backups[:remove] {
+		for _, path := range b.paths {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile gzips path into path.gz and removes path. On failure, path is kept.
+func compressFile(path string, perm os.FileMode) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(path + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
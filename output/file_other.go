@@ -0,0 +1,9 @@
+//go:build !unix
+
+package output
+
+// ReopenOnSIGHUP reopens the files each time the process receives SIGHUP.
+// This signal does not exist on this platform, so it does nothing.
+func ReopenOnSIGHUP(files ...*File) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package output
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ReopenOnSIGHUP reopens the files each time the process receives SIGHUP,
+// as expected by logrotate when configured without copytruncate.
+// The returned function stops listening for the signal.
+func ReopenOnSIGHUP(files ...*File) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				for _, f := range files {
+					f.Reopen()
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	defer f.Close()
+	stop := ReopenOnSIGHUP(f)
+	defer stop()
+
+	f.Write([]byte("before\n"))
+	os.Rename(path, filepath.Join(dir, "app.log.1"))
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !fileExists(path) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	f.Write([]byte("after\n"))
+	if b, _ := os.ReadFile(path); string(b) != "after\n" {
+		t.Errorf("Reopened file mismatch %q (actual) != %q (expected)", b, "after\n")
+	}
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Network is an io.Writer sending each write to a network address, typically a log collector.
+// The connection is established on first write and re-established once when a write fails,
+// or beforehand when the peer closed a stream connection. It is safe for concurrent use.
+type Network struct {
+	network string
+	address string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn *conn
+}
+
+// conn is a connection whose peer closing it is detected by a reading goroutine.
+// Without it, the first write after the peer closed a stream connection succeeds locally and is lost.
+type conn struct {
+	net.Conn
+	closed atomic.Bool
+}
+
+// NewNetwork returns a Network writer for the given network ("tcp", "udp", "unix"...) and address.
+// timeout bounds both dialing and each write, 0 means no timeout.
+func NewNetwork(network, address string, timeout time.Duration) *Network {
+	return &Network{network: network, address: address, timeout: timeout}
+}
+
+// Write sends p to the connection, reconnecting once on failure.
+func (n *Network) Write(p []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	written, err := n.write(p)
+	if err != nil {
+		n.closeConn()
+		written, err = n.write(p)
+	}
+	return written, err
+}
+
+// Close closes the current connection, if any. A later write reconnects.
+func (n *Network) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.closeConn()
+}
+
+func (n *Network) write(p []byte) (int, error) {
+	if n.conn != nil && n.conn.closed.Load() {
+		n.closeConn()
+	}
+	if n.conn == nil {
+		c, err := net.DialTimeout(n.network, n.address, n.timeout)
+		if err != nil {
+			return 0, err
+		}
+		n.conn = &conn{Conn: c}
+		if n.isStream() {
+			go n.conn.watch()
+		}
+	}
+	if n.timeout > 0 {
+		n.conn.SetWriteDeadline(time.Now().Add(n.timeout))
+	}
+	return n.conn.Write(p)
+}
+
+func (n *Network) isStream() bool {
+	return strings.HasPrefix(n.network, "tcp") || n.network == "unix"
+}
+
+// watch reads, and discards, from the connection until it fails, then marks it closed.
+func (c *conn) watch() {
+	io.Copy(io.Discard, c.Conn)
+	c.closed.Store(true)
+}
+
+func (n *Network) closeConn() error {
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
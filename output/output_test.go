@@ -0,0 +1,187 @@
+package output
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInterface(t *testing.T) {
+	var _ io.WriteCloser = &File{}
+	var _ io.WriteCloser = &Network{}
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewFile(path, MaxSize(10), MaxBackups(2))
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	for _, s := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	f.Close()
+
+	b, _ := os.ReadFile(path)
+	if string(b) != "fourth\n" {
+		t.Errorf("Current file mismatch %q (actual) != %q (expected)", b, "fourth\n")
+	}
+	backups, _ := f.backups()
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %v", backups)
+	}
+	if b, _ := os.ReadFile(backups[1].path); string(b) != "third\n" {
+		t.Errorf("Newest backup mismatch %q (actual) != %q (expected)", b, "third\n")
+	}
+	if _, err := f.Write([]byte("closed")); err == nil {
+		t.Errorf("Write after Close must fail")
+	}
+}
+
+func TestFileConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewFile(path, MaxSize(100))
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				f.Write([]byte("0123456789\n"))
+			}
+		}()
+	}
+	wg.Wait()
+	f.Close()
+
+	backups, _ := f.backups()
+	total := 0
+	for _, b := range append(backups, backup{path: path}) {
+		b, _ := os.ReadFile(b.path)
+		total += strings.Count(string(b), "0123456789\n")
+	}
+	if total != 160 {
+		t.Errorf("Expected 160 lines across files, got %d", total)
+	}
+}
+
+func TestFileTimeRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewFile(path, RotateEvery(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("before\n"))
+	time.Sleep(25 * time.Millisecond)
+	f.Write([]byte("after\n"))
+
+	if b, _ := os.ReadFile(path); string(b) != "after\n" {
+		t.Errorf("Current file mismatch %q (actual) != %q (expected)", b, "after\n")
+	}
+	if backups, _ := f.backups(); len(backups) != 1 {
+		t.Errorf("Expected 1 backup, got %v", backups)
+	}
+}
+
+func TestFileCompressAndMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := filepath.Join(dir, "app-"+time.Now().Add(-48*time.Hour).Format(backupTimeFormat)+".log.gz")
+	os.WriteFile(old, nil, 0o644)
+
+	f, err := NewFile(path, Compress(), MaxAge(24*time.Hour), Perm(0o600))
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	f.Write([]byte("compressed\n"))
+	if err := f.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	f.Close()
+
+	if fileExists(old) {
+		t.Errorf("Backups older than MaxAge must be removed")
+	}
+	backups, _ := f.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0].path, ".log.gz") {
+		t.Fatalf("Expected 1 compressed backup, got %v", backups)
+	}
+	info, _ := os.Stat(backups[0].path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Backup permissions mismatch %v (actual) != %v (expected)", info.Mode().Perm(), os.FileMode(0o600))
+	}
+	gzf, _ := os.Open(backups[0].path)
+	defer gzf.Close()
+	r, err := gzip.NewReader(gzf)
+	if err != nil {
+		t.Fatalf("Backup is not gzipped: %v", err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "compressed\n" {
+		t.Errorf("Backup content mismatch %q (actual) != %q (expected)", b, "compressed\n")
+	}
+}
+
+func TestFileFailedRotation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	defer f.Close()
+	f.Write([]byte("lost\n"))
+
+	os.RemoveAll(dir)
+	if err := f.Rotate(); err == nil {
+		t.Fatalf("Rotate must fail without its directory")
+	}
+	if _, err := f.Write([]byte("failed\n")); err == nil {
+		t.Errorf("Write must fail without its directory")
+	}
+	os.MkdirAll(dir, 0o755)
+	if _, err := f.Write([]byte("resumed\n")); err != nil {
+		t.Fatalf("Write after a failed rotation failed: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "resumed\n" {
+		t.Errorf("Current file mismatch %q (actual) != %q (expected)", b, "resumed\n")
+	}
+}
+
+func TestFileBackupsBeingCompressed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Now()
+	var names []string
+	for i := 3; i > 0; i-- {
+		names = append(names, filepath.Join(dir, "app-"+now.Add(-time.Duration(i)*time.Hour).Format(backupTimeFormat)+".log"))
+	}
+	for _, name := range names {
+		os.WriteFile(name, nil, 0o644)
+	}
+	// The newest backup is being compressed.
+	os.WriteFile(names[2]+compressSuffix, nil, 0o644)
+
+	f := &File{path: path, maxBackups: 2}
+	backups, _ := f.backups()
+	if len(backups) != 3 || backups[2].path != names[2] {
+		t.Fatalf("Expected 3 backups, the newest one uncompressed, got %v", backups)
+	}
+	if err := f.removeBackups(); err != nil {
+		t.Fatalf("removeBackups failed: %v", err)
+	}
+	if fileExists(names[0]) || !fileExists(names[1]) || !fileExists(names[2]) || !fileExists(names[2]+compressSuffix) {
+		t.Errorf("Only the oldest backup must be removed")
+	}
+}
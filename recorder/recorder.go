@@ -0,0 +1,151 @@
+// Package recorder provides a flight recorder: entries below the active level are kept
+// in memory and logged only when an error occurs or on demand.
+package recorder
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/internal/entry"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// RecordedAtKey is the field holding the original time of the entries logged by Dump.
+const RecordedAtKey = "recorded_at"
+
+// Recorder is a ring buffer of the last entries below the active level of its loggers.
+// Its memory is bounded by its size and by entry.MaxMessageSize per message.
+// It is safe for concurrent use.
+type Recorder struct {
+	min    mappers.Leveler
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	entries []entry.Entry
+	next    int
+	full    bool
+}
+
+// Entry is a recorded entry, as reported by Entries and the HTTP handler.
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Level   mappers.Level  `json:"level"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// New returns a Recorder keeping the last size entries below min, dropping the ones
+// older than maxAge when maxAge is positive.
+func New(min mappers.Leveler, size int, maxAge time.Duration) *Recorder {
+	return &Recorder{min: min, maxAge: maxAge, entries: make([]entry.Entry, max(size, 1))}
+}
+
+// NewLogger returns a Contextual logger passing the entries at or above the active level
+// of r to l, and recording the others in r. When an Error, Fatal or Panic entry is logged,
+// the recorded entries are dumped first.
+// l should not filter levels itself, otherwise recorded entries are lost when dumped.
+func NewLogger(l loggers.Contextual, r *Recorder) loggers.Contextual {
+	return entry.NewLogger(l, capturer{r})
+}
+
+// capturer implements entry.Capturer for a Recorder.
+type capturer struct {
+	*Recorder
+}
+
+func (c capturer) Capture(lev mappers.Level) bool {
+	return lev < c.min.Level()
+}
+
+func (c capturer) Captured(e entry.Entry) {
+	c.record(e)
+}
+
+// Logging dumps the recorded entries before an error.
+func (c capturer) Logging(lev mappers.Level) {
+	if lev >= mappers.LevelError {
+		c.Dump()
+	}
+}
+
+func (r *Recorder) record(e entry.Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Dump logs the recorded entries, oldest first, to the loggers they were recorded from,
+// with their original time as the RecordedAtKey field, and clears the recorder.
+// It returns the number of entries logged.
+func (r *Recorder) Dump() int {
+	entries := r.take(true)
+	for _, e := range entries {
+		e.Emit(RecordedAtKey, e.Time.Format(time.RFC3339Nano))
+	}
+	return len(entries)
+}
+
+// Entries returns the recorded entries, oldest first, without clearing the recorder.
+func (r *Recorder) Entries() []Entry {
+	entries := r.take(false)
+	out := make([]Entry, len(entries))
+	for i, e := range entries {
+		out[i] = Entry{Time: e.Time, Level: e.Level, Message: e.Message, Fields: e.FieldMap()}
+	}
+	return out
+}
+
+// ServeHTTP returns the recorded entries as JSON on GET, and dumps them on POST,
+// returning the number of entries logged as {"dumped": n}.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var v any
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		v = r.Entries()
+	case http.MethodPost:
+		v = map[string]int{"dumped": r.Dump()}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// take returns the entries younger than maxAge, oldest first, optionally clearing the ring.
+func (r *Recorder) take(clear bool) []entry.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ordered []entry.Entry
+	if r.full {
+		ordered = append(ordered, r.entries[r.next:]...)
+	}
+	ordered = append(ordered, r.entries[:r.next]...)
+	if clear {
+		for i := range r.entries {
+			r.entries[i] = entry.Entry{}
+		}
+		r.next, r.full = 0, false
+	}
+
+	if r.maxAge <= 0 {
+		return ordered
+	}
+	oldest := time.Now().Add(-r.maxAge)
+	for i, e := range ordered {
+		if !e.Time.Before(oldest) {
+			return ordered[i:]
+		}
+	}
+	return nil
+}
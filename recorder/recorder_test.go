@@ -0,0 +1,88 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+func newRecordedLog(r *Recorder) (loggers.Contextual, *bytes.Buffer) {
+	b := new(bytes.Buffer)
+	return NewLogger(stdlib.NewLogger(log.New(b, "", 0)), r), b
+}
+
+func TestRecorderDumpOnError(t *testing.T) {
+	r := New(mappers.LevelInfo, 2, 0)
+	l, b := newRecordedLog(r)
+
+	l.Debug("first")
+	l.WithField("step", 2).Debugf("second %d", 2)
+	l.Debugln("third")
+	l.Info("info")
+	if strings.Contains(b.String(), "DEBUG") {
+		t.Fatalf("Debug entries must be recorded, got %q", b.String())
+	}
+
+	l.Error("failure")
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %q", b.String())
+	}
+	if !strings.HasPrefix(lines[1], "DEBUG second 2 [step=2, recorded_at=") {
+		t.Errorf("Dumped entry mismatch %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "DEBUG third [recorded_at=") {
+		t.Errorf("Dumped entry mismatch %q", lines[2])
+	}
+	if lines[3] != "ERROR failure" {
+		t.Errorf("Error entry mismatch %q", lines[3])
+	}
+	if n := r.Dump(); n != 0 {
+		t.Errorf("Dump must clear the recorder, %d entries left", n)
+	}
+}
+
+func TestRecorderMaxAge(t *testing.T) {
+	r := New(mappers.LevelInfo, 10, 20*time.Millisecond)
+	l, _ := newRecordedLog(r)
+
+	l.Debug("old")
+	time.Sleep(30 * time.Millisecond)
+	l.Debug("recent")
+
+	entries := r.Entries()
+	if len(entries) != 1 || entries[0].Message != "recent" {
+		t.Errorf("Entries mismatch %+v", entries)
+	}
+}
+
+func TestRecorderHTTP(t *testing.T) {
+	r := New(mappers.NewLevelVar(mappers.LevelWarn), 10, 0)
+	l, b := newRecordedLog(r)
+	l.WithField("user", "bob").Info("recorded")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var entries []Entry
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatalf("Failed to decode entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Fields["user"] != "bob" || entries[0].Level != mappers.LevelInfo {
+		t.Errorf("Entries mismatch %+v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if strings.TrimSpace(rec.Body.String()) != `{"dumped":1}` || !strings.Contains(b.String(), "INFO  recorded") {
+		t.Errorf("Dump mismatch %q, output %q", rec.Body.String(), b.String())
+	}
+}
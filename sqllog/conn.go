@@ -0,0 +1,248 @@
+package sqllog
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// conn logs the queries of a connection. The optional interfaces it implements return
+// driver.ErrSkip when the wrapped connection does not, so that database/sql falls back.
+type conn struct {
+	conn driver.Conn
+	log  *queryLogger
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		c.log.log(ctx, "prepare", query, nil, -1, time.Now(), err)
+		return nil, err
+	}
+	return &stmt{stmt: s, conn: c.conn, query: query, log: c.log}, nil
+}
+
+func (c *conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var t driver.Tx
+	var err error
+	if bc, ok := c.conn.(driver.ConnBeginTx); ok {
+		t, err = bc.BeginTx(ctx, opts)
+	} else {
+		t, err = c.conn.Begin()
+	}
+	c.log.log(ctx, "begin", "", nil, -1, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{tx: t, ctx: ctx, log: c.log}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, query, args)
+	c.log.log(ctx, "exec", query, args, rowsAffected(res, err), start, err)
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	c.log.log(ctx, "query", query, args, -1, start, err)
+	return rows, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	if nc, ok := c.conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+// rowsAffected returns the rows affected of a result, or -1 if unknown.
+func rowsAffected(res driver.Result, err error) int64 {
+	if err != nil || res == nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+type stmt struct {
+	stmt  driver.Stmt
+	conn  driver.Conn
+	query string
+	log   *queryLogger
+}
+
+func (s *stmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if ec, ok := s.stmt.(driver.StmtExecContext); ok {
+		res, err = ec.ExecContext(ctx, args)
+	} else if err = ctx.Err(); err == nil {
+		res, err = s.stmt.Exec(values(args))
+	}
+	s.log.log(ctx, "exec", s.query, args, rowsAffected(res, err), start, err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if qc, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else if err = ctx.Err(); err == nil {
+		rows, err = s.stmt.Query(values(args))
+	}
+	s.log.log(ctx, "query", s.query, args, -1, start, err)
+	return rows, err
+}
+
+// CheckNamedValue checks v as database/sql does with unwrapped statements: with the
+// NamedValueChecker of the statement, else the one of its connection, else its ColumnConverter.
+func (s *stmt) CheckNamedValue(v *driver.NamedValue) error {
+	if nc, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return s.converted(v, nc.CheckNamedValue(v))
+	}
+	if nc, ok := s.conn.(driver.NamedValueChecker); ok {
+		return s.converted(v, nc.CheckNamedValue(v))
+	}
+	return s.converted(v, driver.ErrSkip)
+}
+
+// converted returns the error of a NamedValueChecker, converting v with the ColumnConverter
+// of the statement when the checker skipped it.
+func (s *stmt) converted(v *driver.NamedValue, err error) error {
+	cc, ok := s.stmt.(driver.ColumnConverter)
+	if err != driver.ErrSkip || !ok {
+		return err
+	}
+	index := v.Ordinal - 1
+	if n := s.stmt.NumInput(); n >= 0 && index >= n {
+		return driver.ErrSkip
+	}
+	if vr, ok := v.Value.(driver.Valuer); ok {
+		sv, err := vr.Value()
+		if err != nil {
+			return err
+		}
+		if !driver.IsValue(sv) {
+			return fmt.Errorf("non-subset type %T returned from Value", sv)
+		}
+		v.Value = sv
+	}
+	arg := v.Value
+	if v.Value, err = cc.ColumnConverter(index).ConvertValue(arg); err != nil {
+		return err
+	}
+	if !driver.IsValue(v.Value) {
+		return fmt.Errorf("driver ColumnConverter error converted %T to unsupported type %T", arg, v.Value)
+	}
+	return nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	v := make([]driver.Value, len(args))
+	for i, a := range args {
+		v[i] = a.Value
+	}
+	return v
+}
+
+type tx struct {
+	tx  driver.Tx
+	ctx context.Context
+	log *queryLogger
+}
+
+func (t *tx) Commit() error {
+	start := time.Now()
+	err := t.tx.Commit()
+	t.log.log(t.ctx, "commit", "", nil, -1, start, err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	start := time.Now()
+	err := t.tx.Rollback()
+	t.log.log(t.ctx, "rollback", "", nil, -1, start, err)
+	return err
+}
@@ -0,0 +1,167 @@
+// Package sqllog wraps database/sql drivers to log the queries, their duration and their errors.
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/log"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// Field keys of the query entries.
+const (
+	QueryKey    = "query"
+	ArgsKey     = "args"
+	RowsKey     = "rows"
+	DurationKey = "duration"
+	ErrorKey    = "error"
+)
+
+// Options configures the logging of a wrapped driver. The zero value is usable.
+type Options struct {
+	// SlowThreshold escalates the queries lasting longer to Warn level, 0 disables it.
+	SlowThreshold time.Duration
+	// Redact returns the logged value of a query argument, e.g. RedactAll. Arguments are logged as is if nil.
+	Redact func(query string, arg driver.NamedValue) any
+}
+
+// RedactAll replaces every argument by mappers.RedactedValue.
+func RedactAll(query string, arg driver.NamedValue) any {
+	return mappers.RedactedValue
+}
+
+// Register registers under name a driver logging the queries of d, see Wrap.
+func Register(name string, d driver.Driver, l loggers.Contextual, opts Options) {
+	sql.Register(name, Wrap(d, l, opts))
+}
+
+// Wrap returns a driver logging the queries of d, at Debug level, Warn if slow and Error if failed,
+// with the query, arguments, rows affected, duration and error fields. Failures on bad connections,
+// retried by database/sql, are logged at Warn level and canceled queries at Debug level. Queries are logged by the
+// logger of their context, stored by ctxlog.NewContext, if any, else l, else the global logger.
+func Wrap(d driver.Driver, l loggers.Contextual, opts Options) driver.Driver {
+	lg := &queryLogger{logger: l, opts: opts}
+	if dc, ok := d.(driver.DriverContext); ok {
+		return &driverContext{wrappedDriver{driver: d, log: lg}, dc}
+	}
+	return &wrappedDriver{driver: d, log: lg}
+}
+
+// WrapConnector returns a connector logging the queries of c, to be used with sql.OpenDB, see Wrap.
+func WrapConnector(c driver.Connector, l loggers.Contextual, opts Options) driver.Connector {
+	lg := &queryLogger{logger: l, opts: opts}
+	return &connector{connector: c, driver: &wrappedDriver{driver: c.Driver(), log: lg}, log: lg}
+}
+
+// queryLogger logs the queries of a wrapped driver.
+type queryLogger struct {
+	logger loggers.Contextual
+	opts   Options
+}
+
+// log logs an operation which started at start.
+func (q *queryLogger) log(ctx context.Context, op, query string, args []driver.NamedValue, rows int64, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	l, ok := ctxlog.Lookup(ctx)
+	if !ok {
+		if l = q.logger; l == nil {
+			l = log.Default()
+		}
+	}
+	duration := time.Since(start)
+	fields := []any{}
+	if query != "" {
+		fields = append(fields, QueryKey, query)
+	}
+	if len(args) > 0 {
+		values := make([]any, len(args))
+		for i, a := range args {
+			values[i] = a.Value
+			if q.opts.Redact != nil {
+				values[i] = q.opts.Redact(query, a)
+			}
+		}
+		fields = append(fields, ArgsKey, values)
+	}
+	if rows >= 0 {
+		fields = append(fields, RowsKey, rows)
+	}
+	fields = append(fields, DurationKey, duration)
+
+	lev := mappers.LevelDebug
+	switch {
+	case errors.Is(err, context.Canceled):
+		fields = append(fields, ErrorKey, err.Error())
+	case errors.Is(err, driver.ErrBadConn):
+		// database/sql discards the connection and retries.
+		lev = mappers.LevelWarn
+		fields = append(fields, ErrorKey, err.Error())
+	case err != nil:
+		lev = mappers.LevelError
+		fields = append(fields, ErrorKey, err.Error())
+	case q.opts.SlowThreshold > 0 && duration >= q.opts.SlowThreshold:
+		lev = mappers.LevelWarn
+		op = "slow " + op
+	}
+	mappers.Dispatch(ctxlog.DefaultCorrelator.Logger(ctx, l).WithFields(fields...), lev, "sql ", op)
+}
+
+type wrappedDriver struct {
+	driver driver.Driver
+	log    *queryLogger
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{conn: c, log: d.log}, nil
+}
+
+type driverContext struct {
+	wrappedDriver
+	dc driver.DriverContext
+}
+
+func (d *driverContext) OpenConnector(name string) (driver.Connector, error) {
+	c, err := d.dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{connector: c, driver: d, log: d.log}, nil
+}
+
+type connector struct {
+	connector driver.Connector
+	driver    driver.Driver
+	log       *queryLogger
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{conn: cn, log: c.log}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close closes the wrapped connector if it is an io.Closer, as sql.DB.Close does.
+func (c *connector) Close() error {
+	if cl, ok := c.connector.(interface{ Close() error }); ok {
+		return cl.Close()
+	}
+	return nil
+}
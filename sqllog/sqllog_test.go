@@ -0,0 +1,291 @@
+package sqllog
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	stdlog "log"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/ctxlog"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+func newBufferedLog() (loggers.Contextual, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return stdlib.NewLogger(stdlog.New(&buf, "", 0)), &buf
+}
+
+// fakeDriver is an in-memory driver. Its statements affect as many rows as they have arguments,
+// return their arguments as rows, fail on queries containing "fail", "badconn" or "canceled" and sleep on "slow".
+// Its connections implement ExecerContext and QueryerContext when direct is set.
+type fakeDriver struct {
+	direct bool
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	if d.direct {
+		return &fakeDirectConn{}, nil
+	}
+	return &fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "invalid") {
+		return nil, errors.New("syntax error")
+	}
+	return &fakeStmt{query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeDirectConn struct {
+	fakeConn
+}
+
+func (c *fakeDirectConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return (&fakeStmt{query: query}).Exec(values(args))
+}
+
+func (c *fakeDirectConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return (&fakeStmt{query: query}).Query(values(args))
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) run() error {
+	if strings.Contains(s.query, "slow") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if strings.Contains(s.query, "fail") {
+		return errors.New("query failed")
+	}
+	if strings.Contains(s.query, "badconn") {
+		return driver.ErrBadConn
+	}
+	if strings.Contains(s.query, "canceled") {
+		return context.Canceled
+	}
+	return nil
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.run(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(args)), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.run(); err != nil {
+		return nil, err
+	}
+	return &fakeRows{values: args}, nil
+}
+
+type fakeRows struct {
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"v"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func openDB(t *testing.T, direct bool, l loggers.Contextual, opts Options) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(WrapConnector(&fakeConnector{fakeDriver{direct}}, l, opts))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type fakeConnector struct {
+	driver fakeDriver
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open("") }
+func (c *fakeConnector) Driver() driver.Driver                        { return c.driver }
+
+func TestQueries(t *testing.T) {
+	for _, direct := range []bool{false, true} {
+		l, buf := newBufferedLog()
+		db := openDB(t, direct, l, Options{})
+
+		if _, err := db.Exec("UPDATE t SET a = ? WHERE b = ?", 1, "x"); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		rows, err := db.Query("SELECT ?", "y")
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		rows.Close()
+		if _, err := db.Exec("fail"); err == nil {
+			t.Fatalf("Expected an error")
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Direct %t: lines mismatch %q", direct, buf.String())
+		}
+		patterns := []string{
+			`^DEBUG sql exec \[query=UPDATE t SET a = \? WHERE b = \?, args=\[1 x\], rows=2, duration=\S+\]$`,
+			`^DEBUG sql query \[query=SELECT \?, args=\[y\], duration=\S+\]$`,
+			`^ERROR sql exec \[query=fail, duration=\S+, error=query failed\]$`,
+		}
+		for i, p := range patterns {
+			if !regexp.MustCompile(p).MatchString(lines[i]) {
+				t.Errorf("Direct %t: line mismatch %q (actual) != %q (expected)", direct, lines[i], p)
+			}
+		}
+	}
+}
+
+func TestSlowAndRedacted(t *testing.T) {
+	l, buf := newBufferedLog()
+	db := openDB(t, false, l, Options{SlowThreshold: 10 * time.Millisecond, Redact: RedactAll})
+
+	if _, err := db.Exec("slow", "secret"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	pattern := `^WARN  sql slow exec \[query=slow, args=\[\[REDACTED\]\], rows=1, duration=\S+\]$`
+	if line := strings.TrimSpace(buf.String()); !regexp.MustCompile(pattern).MatchString(line) {
+		t.Errorf("Line mismatch %q (actual) != %q (expected)", line, pattern)
+	}
+}
+
+func TestExpectedErrors(t *testing.T) {
+	l, buf := newBufferedLog()
+	db := openDB(t, false, l, Options{})
+
+	if _, err := db.Exec("badconn"); !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("Exec error mismatch %v (actual) != %v (expected)", err, driver.ErrBadConn)
+	}
+	if _, err := db.Exec("canceled"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Exec error mismatch %v (actual) != %v (expected)", err, context.Canceled)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Lines mismatch %q", buf.String())
+	}
+	for _, line := range lines[:len(lines)-1] {
+		if !strings.HasPrefix(line, "WARN  sql exec [query=badconn, duration=") {
+			t.Errorf("Line mismatch %q (actual) != bad connection at Warn level (expected)", line)
+		}
+	}
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "DEBUG sql exec [query=canceled, duration=") || !strings.HasSuffix(last, "error=context canceled]") {
+		t.Errorf("Line mismatch %q (actual) != canceled query at Debug level (expected)", last)
+	}
+}
+
+func TestContextLoggerAndTx(t *testing.T) {
+	l, buf := newBufferedLog()
+	db := openDB(t, false, nil, Options{})
+
+	ctx := ctxlog.NewContext(context.Background(), l.WithField("request_id", "r1"))
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT"); err != nil {
+		t.Fatalf("ExecContext failed: %v", err)
+	}
+	if _, err := tx.PrepareContext(ctx, "invalid"); err == nil {
+		t.Fatalf("Expected a prepare error")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	expected := []string{"DEBUG sql begin [request_id=r1, duration=", "DEBUG sql exec [request_id=r1, query=INSERT, rows=0, duration=",
+		"ERROR sql prepare [request_id=r1, query=invalid, duration=", "DEBUG sql commit [request_id=r1, duration="}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Lines mismatch %q", buf.String())
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(lines[i], e) {
+			t.Errorf("Line mismatch %q (actual) != %q... (expected)", lines[i], e)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	l, buf := newBufferedLog()
+	Register("sqllog-fake", fakeDriver{}, l, Options{})
+	db, err := sql.Open("sqllog-fake", "")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("DELETE"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "DEBUG sql exec [query=DELETE") {
+		t.Errorf("Output mismatch %q", buf.String())
+	}
+}
+
+// point is an argument type only accepted by checkingConn.
+type point struct{ X, Y int }
+
+// checkingConn is a fakeConn converting point arguments with a connection NamedValueChecker.
+type checkingConn struct {
+	fakeConn
+}
+
+func (c *checkingConn) CheckNamedValue(v *driver.NamedValue) error {
+	if p, ok := v.Value.(point); ok {
+		v.Value = fmt.Sprintf("(%d,%d)", p.X, p.Y)
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+type checkingConnector struct{}
+
+func (checkingConnector) Connect(context.Context) (driver.Conn, error) { return &checkingConn{}, nil }
+func (checkingConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+func TestConnNamedValueChecker(t *testing.T) {
+	l, _ := newBufferedLog()
+	db := sql.OpenDB(WrapConnector(checkingConnector{}, l, Options{}))
+	defer db.Close()
+
+	s, err := db.Prepare("SELECT ?")
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	defer s.Close()
+	var v string
+	if err := s.QueryRow(point{1, 2}).Scan(&v); err != nil {
+		t.Fatalf("QueryRow failed: %v", err)
+	}
+	if v != "(1,2)" {
+		t.Errorf("Value mismatch %q (actual) != %q (expected)", v, "(1,2)")
+	}
+}
@@ -0,0 +1,154 @@
+// Package tail provides request scoped loggers buffering their low level entries,
+// which are logged only if the request fails or is slow: tail-based logging at request granularity.
+//
+// Committed entries go through the wrapped logger, so those below its level are lost: to keep the
+// Debug entries of failed requests, wrap a logger letting Debug entries through.
+package tail
+
+import (
+	"sync"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/internal/entry"
+	"github.com/marcaudefroy/loggers/mappers"
+)
+
+// BufferedAtKey is the field holding the original time of the entries logged by Commit.
+const BufferedAtKey = "buffered_at"
+
+// DefaultMaxEntries is the number of entries buffered when Options.MaxEntries is 0.
+const DefaultMaxEntries = 1000
+
+// Options configures a Logger.
+type Options struct {
+	// BufferBelow is the level below which entries are buffered, LevelWarn when nil,
+	// i.e. Debug and Info entries are buffered.
+	BufferBelow mappers.Leveler
+	// MaxEntries bounds the buffer, the oldest entries being dropped, DefaultMaxEntries when 0.
+	MaxEntries int
+	// SlowThreshold makes End commit when the logger lived longer, 0 disables it.
+	SlowThreshold time.Duration
+}
+
+// Logger is a Contextual logger buffering the entries below a level until Commit or Discard is called.
+// Entries at or above that level are logged immediately. Loggers derived with WithField and
+// WithFields share the buffer. Once committed or discarded, every entry is logged immediately.
+type Logger struct {
+	loggers.Contextual
+	buf *buffer
+}
+
+type buffer struct {
+	below    mappers.Leveler
+	slow     time.Duration
+	start    time.Time
+	capacity int
+
+	mu      sync.Mutex
+	entries []entry.Entry
+	dropped int
+	failed  bool
+	done    bool
+}
+
+// New returns a Logger buffering entries before passing them to l.
+// Entries are buffered whatever the level of l, but those below it are dropped by l when committed.
+func New(l loggers.Contextual, opts Options) *Logger {
+	b := buffer{below: opts.BufferBelow, slow: opts.SlowThreshold, start: time.Now(), capacity: opts.MaxEntries}
+	if b.below == nil {
+		b.below = mappers.LevelWarn
+	}
+	if b.capacity <= 0 {
+		b.capacity = DefaultMaxEntries
+	}
+	return &Logger{Contextual: entry.NewLogger(l, &b), buf: &b}
+}
+
+// Commit logs the buffered entries, oldest first, with their original time as the BufferedAtKey field,
+// and ends buffering. If entries were dropped because the buffer was full, a warning says how many.
+// It returns the number of entries logged.
+func (l *Logger) Commit() int {
+	entries, dropped := l.buf.end()
+	if dropped > 0 && len(entries) > 0 {
+		entries[0].Logger().Warnf("%d buffered log entries were dropped", dropped)
+	}
+	for _, e := range entries {
+		e.Emit(BufferedAtKey, e.Time.Format(time.RFC3339Nano))
+	}
+	return len(entries)
+}
+
+// Discard drops the buffered entries and ends buffering.
+func (l *Logger) Discard() {
+	l.buf.end()
+}
+
+// End commits the buffered entries if err is not nil, if an Error or higher entry was logged,
+// or if the logger lived longer than the slow threshold, and discards them otherwise.
+// It reports whether the entries were committed.
+func (l *Logger) End(err error) bool {
+	l.buf.mu.Lock()
+	commit := err != nil || l.buf.failed || (l.buf.slow > 0 && time.Since(l.buf.start) > l.buf.slow)
+	l.buf.mu.Unlock()
+
+	if commit {
+		l.Commit()
+	} else {
+		l.Discard()
+	}
+	return commit
+}
+
+// Failed reports whether an Error or higher entry was logged.
+func (l *Logger) Failed() bool {
+	l.buf.mu.Lock()
+	defer l.buf.mu.Unlock()
+	return l.buf.failed
+}
+
+func (b *buffer) Capture(lev mappers.Level) bool {
+	if lev >= b.below.Level() {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.done
+}
+
+func (b *buffer) Captured(e entry.Entry) {
+	b.mu.Lock()
+	if b.done {
+		// The logger ended between Capture and Captured.
+		b.mu.Unlock()
+		e.Emit()
+		return
+	}
+	defer b.mu.Unlock()
+
+	if len(b.entries) == b.capacity {
+		// The buffer is full: the oldest entry is dropped and counted. Reslicing consumes the
+		// capacity of the array, so append periodically copies the kept entries to a new one and
+		// the dropped entries are released, the memory staying proportional to the capacity.
+		b.entries = b.entries[1:]
+		b.dropped++
+	}
+	b.entries = append(b.entries, e)
+}
+
+func (b *buffer) Logging(lev mappers.Level) {
+	if lev >= mappers.LevelError {
+		b.mu.Lock()
+		b.failed = true
+		b.mu.Unlock()
+	}
+}
+
+func (b *buffer) end() ([]entry.Entry, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries, dropped := b.entries, b.dropped
+	b.entries, b.dropped, b.done = nil, 0, true
+	return entries, dropped
+}
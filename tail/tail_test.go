@@ -0,0 +1,79 @@
+package tail
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcaudefroy/loggers"
+	"github.com/marcaudefroy/loggers/mappers/stdlib"
+)
+
+func TestTailInterface(t *testing.T) {
+	var _ loggers.Contextual = &Logger{}
+}
+
+func newTailLog(opts Options) (*Logger, *bytes.Buffer) {
+	b := new(bytes.Buffer)
+	return New(stdlib.NewLogger(log.New(b, "", 0)), opts), b
+}
+
+func TestTailDiscard(t *testing.T) {
+	l, b := newTailLog(Options{})
+	l.WithField("id", 1).Debug("buffered")
+	l.Info("buffered")
+	l.Warn("immediate")
+
+	if l.End(nil) {
+		t.Errorf("End must discard a successful request")
+	}
+	if b.String() != "WARN  immediate\n" {
+		t.Errorf("Log output mismatch %q (actual) != %q (expected)", b.String(), "WARN  immediate\n")
+	}
+	l.Debug("after end")
+	if !strings.Contains(b.String(), "DEBUG after end") {
+		t.Errorf("Entries after End must be logged immediately, got %q", b.String())
+	}
+}
+
+func TestTailCommitOnError(t *testing.T) {
+	l, b := newTailLog(Options{})
+	l.WithField("id", 1).Debugf("step %d", 1)
+
+	if !l.End(errors.New("boom")) {
+		t.Errorf("End must commit a failed request")
+	}
+	if !strings.HasPrefix(b.String(), "DEBUG step 1 [id=1, buffered_at=") {
+		t.Errorf("Committed entry mismatch %q", b.String())
+	}
+}
+
+func TestTailCommitOnErrorEntry(t *testing.T) {
+	l, b := newTailLog(Options{MaxEntries: 2})
+	l.Info("one")
+	l.Info("two")
+	l.Info("three")
+	l.Error("failure")
+
+	if !l.Failed() || !l.End(nil) {
+		t.Fatalf("End must commit when an error was logged")
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 || lines[0] != "ERROR failure" || lines[1] != "WARN  1 buffered log entries were dropped" ||
+		!strings.HasPrefix(lines[2], "INFO  two") || !strings.HasPrefix(lines[3], "INFO  three") {
+		t.Errorf("Log output mismatch %q", lines)
+	}
+}
+
+func TestTailCommitWhenSlow(t *testing.T) {
+	l, b := newTailLog(Options{SlowThreshold: time.Millisecond})
+	l.Info("slow")
+	time.Sleep(5 * time.Millisecond)
+
+	if !l.End(nil) || !strings.Contains(b.String(), "INFO  slow") {
+		t.Errorf("End must commit a slow request, got %q", b.String())
+	}
+}